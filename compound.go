@@ -0,0 +1,44 @@
+package gsorm
+
+// compoundQuery is a query combined with the builder by a set operator
+type compoundQuery struct {
+	operator string // UNION, UNION ALL, INTERSECT, EXCEPT
+	query    *Builder
+}
+
+// Union combines the results with another query, removing duplicates.
+// OrderBy, Limit and Offset on the receiver apply to the combined result;
+// on the other query they only apply to its own rows.
+func (b *Builder) Union(query *Builder) *Builder {
+	return b.compound("UNION", query)
+}
+
+// UnionAll combines the results with another query, keeping duplicates
+func (b *Builder) UnionAll(query *Builder) *Builder {
+	return b.compound("UNION ALL", query)
+}
+
+// Intersect keeps only rows returned by both queries
+func (b *Builder) Intersect(query *Builder) *Builder {
+	return b.compound("INTERSECT", query)
+}
+
+// Except keeps rows not returned by the other query
+func (b *Builder) Except(query *Builder) *Builder {
+	return b.compound("EXCEPT", query)
+}
+
+func (b *Builder) compound(operator string, query *Builder) *Builder {
+	b.inherit(query)
+	b.compounds = append(b.compounds, compoundQuery{
+		operator: operator,
+		query:    query.Clone(),
+	})
+	return b
+}
+
+// paginated reports whether the query has its own ORDER BY, LIMIT or
+// OFFSET, which must be wrapped when used as a compound branch
+func (b *Builder) paginated() bool {
+	return len(b.orderBy) > 0 || b.limitVal > 0 || b.offsetVal > 0
+}
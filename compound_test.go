@@ -0,0 +1,182 @@
+package gsorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnion(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	young := DB().Table("users").Select("name", "age").Where("age", "<", 28)
+	old := DB().Table("users").Select("name", "age").Where("age", ">", 30)
+
+	builder := young.Union(old).OrderBy("age", "DESC").Limit(5)
+	query, args := builder.buildSelectQuery()
+
+	expectedQuery := "SELECT name, age FROM users WHERE age < ? UNION SELECT name, age FROM users WHERE age > ? ORDER BY age DESC LIMIT ?"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{28, 30, 5}) {
+		t.Errorf("Args not correct: %v", args)
+	}
+
+	results, err := builder.ToArray()
+	if err != nil {
+		t.Fatalf("ToArray() failed: %v", err)
+	}
+
+	if len(results) != 2 || results[0]["name"] != "Bob Johnson" || results[1]["name"] != "John Doe" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+
+	count, err := builder.Count()
+	if err != nil {
+		t.Fatalf("Count() failed: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("Expected count 2, got %d", count)
+	}
+}
+
+func TestUnionAllCount(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	all := DB().Table("users").Select("email")
+	builder := all.Clone().UnionAll(all).OrderBy("email", "ASC")
+
+	count, err := builder.Count()
+	if err != nil {
+		t.Fatalf("Count() failed: %v", err)
+	}
+
+	if count != 8 {
+		t.Errorf("Expected count 8 with duplicates, got %d", count)
+	}
+
+	count, err = all.Clone().Union(all).Count()
+	if err != nil {
+		t.Fatalf("Count() failed: %v", err)
+	}
+
+	if count != 4 {
+		t.Errorf("Expected count 4 without duplicates, got %d", count)
+	}
+
+	sum, err := DB().Table("users").Select("age").UnionAll(DB().Table("users").Select("age").Where("age", ">", 30)).Sum("age")
+	if err != nil {
+		t.Fatalf("Sum() failed: %v", err)
+	}
+
+	if sum != float64(25+30+35+28+35) {
+		t.Errorf("Expected sum over combined rows, got %f", sum)
+	}
+}
+
+func TestIntersectExcept(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	over25 := DB().Table("users").Select("name").Where("age", ">", 25)
+	under32 := DB().Table("users").Select("name").Where("age", "<", 32)
+
+	both, err := over25.Clone().Intersect(under32).OrderBy("name", "ASC").ToArray()
+	if err != nil {
+		t.Fatalf("Intersect ToArray() failed: %v", err)
+	}
+
+	if len(both) != 2 || both[0]["name"] != "Alice Brown" || both[1]["name"] != "Jane Smith" {
+		t.Errorf("Unexpected intersect results: %+v", both)
+	}
+
+	only, err := over25.Clone().Except(under32).ToArray()
+	if err != nil {
+		t.Fatalf("Except ToArray() failed: %v", err)
+	}
+
+	if len(only) != 1 || only[0]["name"] != "Bob Johnson" {
+		t.Errorf("Unexpected except results: %+v", only)
+	}
+}
+
+func TestCompoundPlaceholderOrder(t *testing.T) {
+	first := newBuilder(nil, WithDialect(Postgres)).Table("a").Select("id").Where("x", "=", 1)
+	second := newBuilder(nil, WithDialect(Postgres)).Table("b").Select("id").Where("y", "=", 2)
+	third := newBuilder(nil, WithDialect(Postgres)).Table("c").Select("id").Where("z", "=", 3)
+
+	query, args := first.Union(second).Except(third).Limit(10).Offset(20).buildSelectQuery()
+	query = rebind(Postgres, query)
+
+	expectedQuery := "SELECT id FROM a WHERE x = $1 UNION SELECT id FROM b WHERE y = $2 EXCEPT SELECT id FROM c WHERE z = $3 LIMIT $4 OFFSET $5"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{1, 2, 3, 10, 20}) {
+		t.Errorf("Args not correct: %v", args)
+	}
+}
+
+func TestCompoundBranchLimit(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	young := DB().Table("users").Select("name").Where("age", "<", 28)
+	oldest := DB().Table("users").Select("name").OrderBy("age", "DESC").Limit(1)
+
+	builder := young.UnionAll(oldest)
+	query, args := builder.buildSelectQuery()
+
+	expectedQuery := "SELECT name FROM users WHERE age < ? UNION ALL SELECT * FROM (SELECT name FROM users ORDER BY age DESC LIMIT ?) AS t"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{28, 1}) {
+		t.Errorf("Args not correct: %v", args)
+	}
+
+	results, err := builder.ToArray()
+	if err != nil {
+		t.Fatalf("ToArray() failed: %v", err)
+	}
+
+	if len(results) != 2 || results[0]["name"] != "John Doe" || results[1]["name"] != "Bob Johnson" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+}
+
+func TestCompoundBranchOrderWithOuterOrder(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	young := DB().Table("users").Select("name", "age").Where("age", "<", 28)
+	old := DB().Table("users").Select("name", "age").Where("age", ">", 28).OrderBy("age", "DESC")
+
+	builder := young.Union(old).OrderBy("age", "ASC")
+	query, _ := builder.buildSelectQuery()
+
+	expectedQuery := "SELECT name, age FROM users WHERE age < ? UNION SELECT * FROM (SELECT name, age FROM users WHERE age > ? ORDER BY age DESC) AS t ORDER BY age ASC"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	results, err := builder.ToArray()
+	if err != nil {
+		t.Fatalf("ToArray() failed: %v", err)
+	}
+
+	names := make([]interface{}, len(results))
+	for i, row := range results {
+		names[i] = row["name"]
+	}
+	expected := []interface{}{"John Doe", "Jane Smith", "Bob Johnson"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
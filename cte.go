@@ -0,0 +1,90 @@
+package gsorm
+
+import (
+	"strings"
+)
+
+// commonTable is a named query in the WITH clause
+type commonTable struct {
+	name      string
+	columns   []string
+	anchor    *Builder
+	recursive *Builder // joined to anchor with UNION ALL when set
+}
+
+// With adds a common table expression: WITH name AS (SELECT ...).
+// It is prepended to SELECT, UPDATE and DELETE statements.
+func (b *Builder) With(name string, query *Builder) *Builder {
+	b.inherit(query)
+	b.ctes = append(b.ctes, commonTable{
+		name:   b.ident(name),
+		anchor: query.Clone(),
+	})
+	return b
+}
+
+// WithRecursive adds a recursive common table expression:
+// WITH RECURSIVE name (columns) AS (anchor UNION ALL recursive)
+func (b *Builder) WithRecursive(name string, columns []string, anchor, recursive *Builder) *Builder {
+	b.inherit(anchor)
+	b.inherit(recursive)
+
+	cols := make([]string, len(columns))
+	for i, col := range columns {
+		cols[i] = b.ident(col)
+	}
+
+	b.ctes = append(b.ctes, commonTable{
+		name:      b.ident(name),
+		columns:   cols,
+		anchor:    anchor.Clone(),
+		recursive: recursive.Clone(),
+	})
+	return b
+}
+
+// buildWithClause builds the WITH clause including a trailing space
+func (b *Builder) buildWithClause() (string, []interface{}) {
+	clause := getStringBuilder()
+	defer putStringBuilder(clause)
+
+	args := make([]interface{}, 0)
+	recursive := false
+	for _, cte := range b.ctes {
+		if cte.recursive != nil {
+			recursive = true
+			break
+		}
+	}
+
+	clause.WriteString(b.dialect.WithKeyword(recursive))
+	clause.WriteString(" ")
+
+	for i, cte := range b.ctes {
+		if i > 0 {
+			clause.WriteString(", ")
+		}
+		clause.WriteString(cte.name)
+		if len(cte.columns) > 0 {
+			clause.WriteString(" (")
+			clause.WriteString(strings.Join(cte.columns, ", "))
+			clause.WriteString(")")
+		}
+		clause.WriteString(" AS (")
+
+		anchorQuery, anchorArgs := cte.anchor.buildSelectQuery()
+		clause.WriteString(anchorQuery)
+		args = append(args, anchorArgs...)
+
+		if cte.recursive != nil {
+			recursiveQuery, recursiveArgs := cte.recursive.buildSelectQuery()
+			clause.WriteString(" UNION ALL ")
+			clause.WriteString(recursiveQuery)
+			args = append(args, recursiveArgs...)
+		}
+		clause.WriteString(")")
+	}
+
+	clause.WriteString(" ")
+	return clause.String(), args
+}
@@ -0,0 +1,160 @@
+package gsorm
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func setupCategoriesTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`
+		CREATE TABLE categories (
+			id INTEGER PRIMARY KEY,
+			parent_id INTEGER,
+			name TEXT NOT NULL
+		);
+		INSERT INTO categories (id, parent_id, name) VALUES
+		(1, NULL, 'Electronics'), (2, 1, 'Computers'), (3, 2, 'Laptops'),
+		(4, 2, 'Desktops'), (5, NULL, 'Books'), (6, 5, 'Fiction');
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create categories table: %v", err)
+	}
+}
+
+func TestWith(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	adults := DB().Table("users").Select("id", "name").Where("age", ">=", 30)
+	builder := DB().With("adults", adults).Table("adults").Select("name").Where("name", "!=", "Nobody").OrderBy("name", "ASC")
+
+	query, args := builder.buildSelectQuery()
+
+	expectedQuery := "WITH adults AS (SELECT id, name FROM users WHERE age >= ?) SELECT name FROM adults WHERE name != ? ORDER BY name ASC"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{30, "Nobody"}) {
+		t.Errorf("Args not correct: %v", args)
+	}
+
+	results, err := builder.ToArray()
+	if err != nil {
+		t.Fatalf("ToArray() failed: %v", err)
+	}
+
+	if len(results) != 2 || results[0]["name"] != "Bob Johnson" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+}
+
+func TestWithRecursive(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	setupCategoriesTable(t, db)
+
+	anchor := DB().Table("categories").Select("id", "name", "0").Where("id", "=", 1)
+	recursive := DB().Table("categories").
+		Select("categories.id", "categories.name", "tree.depth + 1").
+		InnerJoin("tree", "categories.parent_id = tree.id").
+		Where("tree.depth", "<", 5)
+
+	builder := DB().
+		WithRecursive("tree", []string{"id", "name", "depth"}, anchor, recursive).
+		Table("tree").
+		Select("name", "depth").
+		OrderBy("depth", "ASC").
+		OrderBy("name", "ASC")
+
+	query, args := builder.buildSelectQuery()
+
+	expectedQuery := "WITH RECURSIVE tree (id, name, depth) AS (SELECT id, name, 0 FROM categories WHERE id = ? UNION ALL SELECT categories.id, categories.name, tree.depth + 1 FROM categories INNER JOIN tree ON categories.parent_id = tree.id WHERE tree.depth < ?) SELECT name, depth FROM tree ORDER BY depth ASC, name ASC"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{1, 5}) {
+		t.Errorf("Args not correct: %v", args)
+	}
+
+	results, err := builder.ToArray()
+	if err != nil {
+		t.Fatalf("ToArray() failed: %v", err)
+	}
+
+	expected := []string{"Electronics", "Computers", "Desktops", "Laptops"}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d categories, got %d", len(expected), len(results))
+	}
+
+	for i, name := range expected {
+		if results[i]["name"] != name {
+			t.Errorf("Expected '%s' at %d, got %v", name, i, results[i]["name"])
+		}
+	}
+}
+
+func TestWithUpdateAndDelete(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	seniors := DB().Table("users").Select("id").Where("age", ">", 29)
+
+	builder := DB().With("seniors", seniors).Table("users").WhereInSub("id", DB().Table("seniors").Select("id"))
+	query, args := builder.buildUpdateQuery(map[string]interface{}{"age": 60})
+
+	expectedQuery := "WITH seniors AS (SELECT id FROM users WHERE age > ?) UPDATE users SET age = ? WHERE id IN (SELECT id FROM seniors)"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{29, 60}) {
+		t.Errorf("Args not correct: %v", args)
+	}
+
+	result, err := builder.Update(map[string]interface{}{"age": 60})
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows != 2 {
+		t.Errorf("Expected 2 rows updated, got %d", rows)
+	}
+
+	result, err = DB().With("seniors", DB().Table("users").Select("id").Where("age", "=", 60)).
+		Table("users").
+		WhereInSub("id", DB().Table("seniors").Select("id")).
+		Delete()
+	if err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows != 2 {
+		t.Errorf("Expected 2 rows deleted, got %d", rows)
+	}
+}
+
+func TestWithPlaceholderOrder(t *testing.T) {
+	anchor := newBuilder(nil, WithDialect(SQLServer)).Table("employees").Select("id", "manager_id").Where("id", "=", 7)
+	recursive := newBuilder(nil, WithDialect(SQLServer)).Table("employees").
+		Select("employees.id", "employees.manager_id").
+		InnerJoin("chain", "employees.id = chain.manager_id")
+
+	query, args := newBuilder(nil, WithDialect(SQLServer)).
+		WithRecursive("chain", []string{"id", "manager_id"}, anchor, recursive).
+		Table("chain").
+		Where("id", "!=", 7).
+		buildSelectQuery()
+	query = rebind(SQLServer, query)
+
+	expectedQuery := "WITH chain (id, manager_id) AS (SELECT id, manager_id FROM employees WHERE id = @p1 UNION ALL SELECT employees.id, employees.manager_id FROM employees INNER JOIN chain ON employees.id = chain.manager_id) SELECT * FROM chain WHERE id != @p2"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	if len(args) != 2 {
+		t.Errorf("Expected 2 args, got %d", len(args))
+	}
+}
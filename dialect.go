@@ -0,0 +1,534 @@
+package gsorm
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect describes the SQL flavour spoken by the target database.
+// Builder always renders statements with "?" markers internally and lets
+// the dialect decide the final placeholder style, quoting and syntax.
+type Dialect interface {
+	// Name returns the dialect identifier, e.g. "postgres"
+	Name() string
+
+	// Placeholder returns the bind marker for the n-th argument (1-based)
+	Placeholder(n int) string
+
+	// QuoteIdent quotes a single identifier part (no dots)
+	QuoteIdent(name string) string
+
+	// LimitOffset renders the pagination clause using "?" markers.
+	// ordered reports whether the statement already has an ORDER BY.
+	LimitOffset(limit, offset int, ordered bool) (string, []interface{})
+
+	// WithKeyword returns the keyword opening a WITH clause; recursive
+	// reports whether any of its common table expressions is recursive
+	WithKeyword(recursive bool) string
+
+	// Upsert renders an INSERT that updates the row on conflict
+	Upsert(spec UpsertSpec) (string, error)
+
+	// Literal renders a value as an inline SQL literal (used by PrintSQL)
+	Literal(value interface{}) string
+
+	// WindowSupport reports the window function features of the dialect
+	WindowSupport() WindowSupport
+
+	// MaxParams returns the most bind parameters a single statement may have
+	MaxParams() int
+
+	// MaxRows returns the most rows a single VALUES list may have, 0 when
+	// only MaxParams limits it
+	MaxRows() int
+
+	// DefaultValues reports whether DEFAULT may be written as a value in
+	// INSERT ... VALUES
+	DefaultValues() bool
+
+	// Returning renders the clause that returns cols (quoted, or "*") of
+	// the rows written by a statement of kind. output reports an OUTPUT
+	// clause written before VALUES/WHERE rather than a trailing RETURNING
+	// clause. clause is empty when the dialect can't return written rows.
+	Returning(kind QueryKind, cols []string) (clause string, output bool)
+}
+
+// WindowSupport describes which window clauses a Dialect accepts.
+// Window expressions are checked against it when added to a query.
+type WindowSupport struct {
+	GroupsFrames   bool // GROUPS frame units
+	RangeOffsets   bool // RANGE frames with n PRECEDING / n FOLLOWING bounds
+	OrderedRanking bool // ranking and offset functions require ORDER BY
+}
+
+// UpsertSpec describes an upsert of one or more rows for a Dialect.
+// The args are those of Values, row after row, followed by the args of
+// Where.
+type UpsertSpec struct {
+	Table           string
+	Columns         []string
+	ConflictColumns []string
+	UpdateColumns   []string // no columns keeps the conflicting row as is
+	Where           string   // condition for updating a conflicting row
+	Values          string   // bound value rows, e.g. "(?, ?), (?, ?)"; one row of "?" when empty
+	Returning       []string // columns to return, rendered with Dialect.Returning
+}
+
+// valueRows returns the value rows of spec
+func (spec UpsertSpec) valueRows() string {
+	if spec.Values != "" {
+		return spec.Values
+	}
+	return "(" + placeholderList(len(spec.Columns)) + ")"
+}
+
+// Built-in dialects
+var (
+	MySQL     Dialect = mysqlDialect{}
+	Postgres  Dialect = postgresDialect{}
+	SQLite    Dialect = sqliteDialect{}
+	SQLServer Dialect = sqlserverDialect{}
+)
+
+// detectDialect guesses the dialect from the driver behind db.
+// Falls back to MySQL, which matches the historical behaviour.
+func detectDialect(db *sql.DB) Dialect {
+	if db == nil {
+		return MySQL
+	}
+
+	driver := strings.ToLower(fmt.Sprintf("%T", db.Driver()))
+	switch {
+	case strings.Contains(driver, "sqlite"):
+		return SQLite
+	case strings.Contains(driver, "pq."), strings.Contains(driver, "pgx"), strings.Contains(driver, "stdlib."):
+		return Postgres
+	case strings.Contains(driver, "mssql"), strings.Contains(driver, "sqlserver"):
+		return SQLServer
+	default:
+		return MySQL
+	}
+}
+
+// MySQL dialect
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func (mysqlDialect) Placeholder(int) string { return "?" }
+
+func (mysqlDialect) QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (mysqlDialect) LimitOffset(limit, offset int, ordered bool) (string, []interface{}) {
+	return limitOffset(limit, offset, "18446744073709551615")
+}
+
+func (mysqlDialect) WithKeyword(recursive bool) string { return withKeyword(recursive) }
+
+func (mysqlDialect) Upsert(spec UpsertSpec) (string, error) {
+	if spec.Where != "" {
+		return "", fmt.Errorf("gsorm: mysql upsert doesn't support a WHERE condition")
+	}
+
+	updates := make([]string, len(spec.UpdateColumns))
+	for i, col := range spec.UpdateColumns {
+		updates[i] = col + " = VALUES(" + col + ")"
+	}
+	if len(updates) == 0 && len(spec.Columns) > 0 {
+		// No-op update keeps the existing row without INSERT IGNORE side effects
+		updates = append(updates, spec.Columns[0]+" = "+spec.Columns[0])
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON DUPLICATE KEY UPDATE %s",
+		spec.Table,
+		strings.Join(spec.Columns, ", "),
+		spec.valueRows(),
+		strings.Join(updates, ", ")), nil
+}
+
+func (mysqlDialect) WindowSupport() WindowSupport {
+	return WindowSupport{RangeOffsets: true}
+}
+
+func (mysqlDialect) MaxParams() int { return 65535 }
+
+func (mysqlDialect) MaxRows() int { return 0 }
+
+func (mysqlDialect) DefaultValues() bool { return true }
+
+// MySQL has no RETURNING; Builder.Returning falls back to reading inserted
+// rows back by LAST_INSERT_ID()
+func (mysqlDialect) Returning(QueryKind, []string) (string, bool) { return "", false }
+
+func (mysqlDialect) Literal(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		v = strings.ReplaceAll(v, `\`, `\\`)
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case []byte:
+		return "X'" + hex.EncodeToString(v) + "'"
+	}
+	return literal(value, "1", "0")
+}
+
+// PostgreSQL dialect
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+func (postgresDialect) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (postgresDialect) LimitOffset(limit, offset int, ordered bool) (string, []interface{}) {
+	return limitOffset(limit, offset, "")
+}
+
+func (postgresDialect) WithKeyword(recursive bool) string { return withKeyword(recursive) }
+
+func (postgresDialect) Upsert(spec UpsertSpec) (string, error) {
+	return onConflictUpsert(spec)
+}
+
+func (postgresDialect) WindowSupport() WindowSupport {
+	return WindowSupport{GroupsFrames: true, RangeOffsets: true}
+}
+
+func (postgresDialect) MaxParams() int { return 65535 }
+
+func (postgresDialect) MaxRows() int { return 0 }
+
+func (postgresDialect) DefaultValues() bool { return true }
+
+func (postgresDialect) Returning(kind QueryKind, cols []string) (string, bool) {
+	return returningClause(cols), false
+}
+
+func (postgresDialect) Literal(value interface{}) string {
+	if v, ok := value.([]byte); ok {
+		return `'\x` + hex.EncodeToString(v) + "'::bytea"
+	}
+	return literal(value, "TRUE", "FALSE")
+}
+
+// SQLite dialect
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) Placeholder(int) string { return "?" }
+
+func (sqliteDialect) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (sqliteDialect) LimitOffset(limit, offset int, ordered bool) (string, []interface{}) {
+	return limitOffset(limit, offset, "-1")
+}
+
+func (sqliteDialect) WithKeyword(recursive bool) string { return withKeyword(recursive) }
+
+func (sqliteDialect) Upsert(spec UpsertSpec) (string, error) {
+	return onConflictUpsert(spec)
+}
+
+func (sqliteDialect) WindowSupport() WindowSupport {
+	return WindowSupport{GroupsFrames: true, RangeOffsets: true}
+}
+
+// SQLITE_MAX_VARIABLE_NUMBER since SQLite 3.32 (999 before)
+func (sqliteDialect) MaxParams() int { return 32766 }
+
+func (sqliteDialect) MaxRows() int { return 0 }
+
+func (sqliteDialect) DefaultValues() bool { return false }
+
+func (sqliteDialect) Returning(kind QueryKind, cols []string) (string, bool) {
+	return returningClause(cols), false
+}
+
+func (sqliteDialect) Literal(value interface{}) string {
+	if v, ok := value.([]byte); ok {
+		return "X'" + hex.EncodeToString(v) + "'"
+	}
+	return literal(value, "1", "0")
+}
+
+// SQL Server dialect
+type sqlserverDialect struct{}
+
+func (sqlserverDialect) Name() string { return "sqlserver" }
+
+func (sqlserverDialect) Placeholder(n int) string { return "@p" + strconv.Itoa(n) }
+
+func (sqlserverDialect) QuoteIdent(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+func (sqlserverDialect) LimitOffset(limit, offset int, ordered bool) (string, []interface{}) {
+	if limit <= 0 && offset <= 0 {
+		return "", nil
+	}
+
+	clause := ""
+	// OFFSET ... FETCH is only valid after ORDER BY
+	if !ordered {
+		clause = " ORDER BY (SELECT NULL)"
+	}
+
+	clause += " OFFSET ? ROWS"
+	args := []interface{}{offset}
+	if limit > 0 {
+		clause += " FETCH NEXT ? ROWS ONLY"
+		args = append(args, limit)
+	}
+	return clause, args
+}
+
+// SQL Server detects recursion itself and rejects the RECURSIVE keyword
+func (sqlserverDialect) WithKeyword(recursive bool) string { return "WITH" }
+
+func (sqlserverDialect) Upsert(spec UpsertSpec) (string, error) {
+	if len(spec.ConflictColumns) == 0 {
+		return "", fmt.Errorf("gsorm: sqlserver upsert requires conflict columns")
+	}
+
+	on := make([]string, len(spec.ConflictColumns))
+	for i, col := range spec.ConflictColumns {
+		on[i] = "target." + col + " = source." + col
+	}
+
+	sources := make([]string, len(spec.Columns))
+	for i, col := range spec.Columns {
+		sources[i] = "source." + col
+	}
+
+	query := fmt.Sprintf("MERGE INTO %s AS target USING (VALUES %s) AS source (%s) ON %s",
+		spec.Table,
+		spec.valueRows(),
+		strings.Join(spec.Columns, ", "),
+		strings.Join(on, " AND "))
+
+	if len(spec.UpdateColumns) > 0 {
+		updates := make([]string, len(spec.UpdateColumns))
+		for i, col := range spec.UpdateColumns {
+			updates[i] = "target." + col + " = source." + col
+		}
+		query += " WHEN MATCHED"
+		if spec.Where != "" {
+			query += " AND (" + spec.Where + ")"
+		}
+		query += " THEN UPDATE SET " + strings.Join(updates, ", ")
+	}
+
+	query += fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)",
+		strings.Join(spec.Columns, ", "),
+		strings.Join(sources, ", "))
+
+	if len(spec.Returning) > 0 {
+		output, _ := sqlserverDialect{}.Returning(KindUpsert, spec.Returning)
+		query += output
+	}
+
+	// MERGE must be terminated with a semicolon
+	return query + ";", nil
+}
+
+// SQL Server only accepts UNBOUNDED and CURRENT ROW bounds in RANGE frames
+func (sqlserverDialect) WindowSupport() WindowSupport {
+	return WindowSupport{OrderedRanking: true}
+}
+
+// sp_executesql, which runs parameterised statements, takes two of the
+// 2100 parameters itself
+func (sqlserverDialect) MaxParams() int { return 2098 }
+
+// SQL Server accepts at most 1000 rows in one INSERT ... VALUES
+func (sqlserverDialect) MaxRows() int { return 1000 }
+
+func (sqlserverDialect) DefaultValues() bool { return true }
+
+func (sqlserverDialect) Returning(kind QueryKind, cols []string) (string, bool) {
+	source := "INSERTED."
+	if kind == KindDelete {
+		source = "DELETED."
+	}
+	outputs := make([]string, len(cols))
+	for i, col := range cols {
+		outputs[i] = source + col
+	}
+	return " OUTPUT " + strings.Join(outputs, ", "), true
+}
+
+func (sqlserverDialect) Literal(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return "N'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	}
+	return literal(value, "1", "0")
+}
+
+// limitOffset renders LIMIT/OFFSET; noLimit is used when only OFFSET is set
+// on databases that require a LIMIT in front of it
+func limitOffset(limit, offset int, noLimit string) (string, []interface{}) {
+	clause := ""
+	args := make([]interface{}, 0, 2)
+
+	if limit > 0 {
+		clause += " LIMIT ?"
+		args = append(args, limit)
+	} else if offset > 0 && noLimit != "" {
+		clause += " LIMIT " + noLimit
+	}
+
+	if offset > 0 {
+		clause += " OFFSET ?"
+		args = append(args, offset)
+	}
+
+	return clause, args
+}
+
+// withKeyword is the standard WITH / WITH RECURSIVE keyword
+func withKeyword(recursive bool) string {
+	if recursive {
+		return "WITH RECURSIVE"
+	}
+	return "WITH"
+}
+
+// onConflictUpsert renders the INSERT ... ON CONFLICT form shared by
+// PostgreSQL and SQLite
+func onConflictUpsert(spec UpsertSpec) (string, error) {
+	if len(spec.ConflictColumns) == 0 {
+		return "", fmt.Errorf("gsorm: ON CONFLICT upsert requires conflict columns")
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON CONFLICT (%s)",
+		spec.Table,
+		strings.Join(spec.Columns, ", "),
+		spec.valueRows(),
+		strings.Join(spec.ConflictColumns, ", "))
+
+	if len(spec.UpdateColumns) == 0 {
+		query += " DO NOTHING"
+	} else {
+		updates := make([]string, len(spec.UpdateColumns))
+		for i, col := range spec.UpdateColumns {
+			updates[i] = col + " = excluded." + col
+		}
+		query += " DO UPDATE SET " + strings.Join(updates, ", ")
+		if spec.Where != "" {
+			query += " WHERE " + spec.Where
+		}
+	}
+
+	if len(spec.Returning) > 0 {
+		query += returningClause(spec.Returning)
+	}
+	return query, nil
+}
+
+// returningClause renders a trailing RETURNING clause
+func returningClause(cols []string) string {
+	return " RETURNING " + strings.Join(cols, ", ")
+}
+
+// literal renders the values that look the same in every dialect
+func literal(value interface{}, trueLit, falseLit string) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05") + "'"
+	case bool:
+		if v {
+			return trueLit
+		}
+		return falseLit
+	case []byte:
+		return "'" + strings.ReplaceAll(string(v), "'", "''") + "'"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// placeholderList returns n comma separated "?" markers
+func placeholderList(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat("?, ", n-1) + "?"
+}
+
+// quoteIdent quotes a dotted identifier part by part, leaving "*" as is
+func quoteIdent(d Dialect, ident string) string {
+	parts := strings.Split(ident, ".")
+	for i, part := range parts {
+		if part != "*" {
+			parts[i] = d.QuoteIdent(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// scanPlaceholders walks query and calls fn for every "?" marker outside
+// of quoted literals and identifiers
+func scanPlaceholders(query string, fn func(sb *strings.Builder, n int)) string {
+	sb := getStringBuilder()
+	defer putStringBuilder(sb)
+
+	n := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			n++
+			fn(sb, n)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+
+	return sb.String()
+}
+
+// rebind rewrites "?" markers into the placeholder style of d
+func rebind(d Dialect, query string) string {
+	if d.Placeholder(1) == "?" {
+		return query
+	}
+	return scanPlaceholders(query, func(sb *strings.Builder, n int) {
+		sb.WriteString(d.Placeholder(n))
+	})
+}
+
+// interpolate inlines args as literals of d, for debugging output only
+func interpolate(d Dialect, query string, args []interface{}) string {
+	return scanPlaceholders(query, func(sb *strings.Builder, n int) {
+		if n > len(args) {
+			sb.WriteByte('?')
+			return
+		}
+		sb.WriteString(d.Literal(args[n-1]))
+	})
+}
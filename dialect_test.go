@@ -0,0 +1,367 @@
+package gsorm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectDialect(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if name := DB().Dialect().Name(); name != "sqlite" {
+		t.Errorf("Expected sqlite dialect, got '%s'", name)
+	}
+
+	if name := detectDialect(nil).Name(); name != "mysql" {
+		t.Errorf("Expected mysql fallback dialect, got '%s'", name)
+	}
+}
+
+func TestDialectSelectQuery(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{MySQL, "SELECT `name`, `email` FROM `users` WHERE `age` > ? AND `status` IN (?,?) ORDER BY `name` ASC LIMIT ? OFFSET ?"},
+		{Postgres, `SELECT "name", "email" FROM "users" WHERE "age" > $1 AND "status" IN ($2,$3) ORDER BY "name" ASC LIMIT $4 OFFSET $5`},
+		{SQLite, `SELECT "name", "email" FROM "users" WHERE "age" > ? AND "status" IN (?,?) ORDER BY "name" ASC LIMIT ? OFFSET ?`},
+		{SQLServer, "SELECT [name], [email] FROM [users] WHERE [age] > @p1 AND [status] IN (@p2,@p3) ORDER BY [name] ASC OFFSET @p4 ROWS FETCH NEXT @p5 ROWS ONLY"},
+	}
+
+	for _, tt := range tests {
+		builder := newBuilder(nil, WithDialect(tt.dialect), WithIdentifierQuoting()).
+			Table("users").
+			Select("name", "email").
+			Where("age", ">", 25).
+			WhereIn("status", []interface{}{"active", "pending"}).
+			OrderBy("name", "ASC").
+			Paginate(3, 10)
+
+		query, args := builder.buildSelectQuery()
+		query = rebind(tt.dialect, query)
+		if query != tt.expected {
+			t.Errorf("[%s] Expected query:\n%s\nGot:\n%s", tt.dialect.Name(), tt.expected, query)
+		}
+
+		if len(args) != 5 {
+			t.Errorf("[%s] Expected 5 args, got %d", tt.dialect.Name(), len(args))
+		}
+	}
+}
+
+func TestDialectLimitOffset(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{MySQL, "SELECT * FROM users LIMIT 18446744073709551615 OFFSET ?"},
+		{Postgres, "SELECT * FROM users OFFSET $1"},
+		{SQLite, "SELECT * FROM users LIMIT -1 OFFSET ?"},
+		{SQLServer, "SELECT * FROM users ORDER BY (SELECT NULL) OFFSET @p1 ROWS"},
+	}
+
+	for _, tt := range tests {
+		query, args := newBuilder(nil, WithDialect(tt.dialect)).Table("users").Offset(20).buildSelectQuery()
+		query = rebind(tt.dialect, query)
+		if query != tt.expected {
+			t.Errorf("[%s] Expected query:\n%s\nGot:\n%s", tt.dialect.Name(), tt.expected, query)
+		}
+
+		if len(args) != 1 || args[0] != 20 {
+			t.Errorf("[%s] Args not correct: %v", tt.dialect.Name(), args)
+		}
+	}
+}
+
+func TestDialectWriteQueries(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		insert  string
+		bulk    string
+		update  string
+		delete  string
+	}{
+		{
+			MySQL,
+			"INSERT INTO `users` (`name`) VALUES (?)",
+			"INSERT INTO `users` (`name`) VALUES (?), (?)",
+			"UPDATE `users` SET `name` = ? WHERE `id` = ?",
+			"DELETE FROM `users` WHERE `id` = ?",
+		},
+		{
+			Postgres,
+			`INSERT INTO "users" ("name") VALUES ($1)`,
+			`INSERT INTO "users" ("name") VALUES ($1), ($2)`,
+			`UPDATE "users" SET "name" = $1 WHERE "id" = $2`,
+			`DELETE FROM "users" WHERE "id" = $1`,
+		},
+		{
+			SQLite,
+			`INSERT INTO "users" ("name") VALUES (?)`,
+			`INSERT INTO "users" ("name") VALUES (?), (?)`,
+			`UPDATE "users" SET "name" = ? WHERE "id" = ?`,
+			`DELETE FROM "users" WHERE "id" = ?`,
+		},
+		{
+			SQLServer,
+			"INSERT INTO [users] ([name]) VALUES (@p1)",
+			"INSERT INTO [users] ([name]) VALUES (@p1), (@p2)",
+			"UPDATE [users] SET [name] = @p1 WHERE [id] = @p2",
+			"DELETE FROM [users] WHERE [id] = @p1",
+		},
+	}
+
+	for _, tt := range tests {
+		builder := newBuilder(nil, WithDialect(tt.dialect), WithIdentifierQuoting()).Table("users")
+
+		query, _ := builder.buildInsertQuery(map[string]interface{}{"name": "John"})
+		if got := rebind(tt.dialect, query); got != tt.insert {
+			t.Errorf("[%s] Expected insert:\n%s\nGot:\n%s", tt.dialect.Name(), tt.insert, got)
+		}
+
+		query, _ = builder.buildInsertBulkQuery([]map[string]interface{}{{"name": "John"}, {"name": "Jane"}})
+		if got := rebind(tt.dialect, query); got != tt.bulk {
+			t.Errorf("[%s] Expected bulk insert:\n%s\nGot:\n%s", tt.dialect.Name(), tt.bulk, got)
+		}
+
+		filtered := builder.Clone().Where("id", "=", 1)
+
+		query, _ = filtered.buildUpdateQuery(map[string]interface{}{"name": "John"})
+		if got := rebind(tt.dialect, query); got != tt.update {
+			t.Errorf("[%s] Expected update:\n%s\nGot:\n%s", tt.dialect.Name(), tt.update, got)
+		}
+
+		query, _ = filtered.buildDeleteQuery()
+		if got := rebind(tt.dialect, query); got != tt.delete {
+			t.Errorf("[%s] Expected delete:\n%s\nGot:\n%s", tt.dialect.Name(), tt.delete, got)
+		}
+	}
+}
+
+func TestDialectUpsert(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{MySQL, "INSERT INTO users (email) VALUES (?) ON DUPLICATE KEY UPDATE email = email"},
+		{Postgres, "INSERT INTO users (email) VALUES ($1) ON CONFLICT (email) DO NOTHING"},
+		{SQLite, "INSERT INTO users (email) VALUES (?) ON CONFLICT (email) DO NOTHING"},
+		{SQLServer, "MERGE INTO users AS target USING (VALUES (@p1)) AS source (email) ON target.email = source.email WHEN NOT MATCHED THEN INSERT (email) VALUES (source.email);"},
+	}
+
+	for _, tt := range tests {
+		query, args, err := newBuilder(nil, WithDialect(tt.dialect)).Table("users").
+			buildUpsertQuery(map[string]interface{}{"email": "john@example.com"}, []string{"email"})
+		if err != nil {
+			t.Fatalf("[%s] buildUpsertQuery() failed: %v", tt.dialect.Name(), err)
+		}
+
+		if got := rebind(tt.dialect, query); got != tt.expected {
+			t.Errorf("[%s] Expected upsert:\n%s\nGot:\n%s", tt.dialect.Name(), tt.expected, got)
+		}
+
+		if len(args) != 1 {
+			t.Errorf("[%s] Expected 1 arg, got %d", tt.dialect.Name(), len(args))
+		}
+	}
+
+	query, _, err := newBuilder(nil, WithDialect(Postgres)).Table("users").
+		buildUpsertQuery(map[string]interface{}{"name": "John"}, []string{"email"})
+	if err != nil {
+		t.Fatalf("buildUpsertQuery() failed: %v", err)
+	}
+
+	expected := "INSERT INTO users (name) VALUES (?) ON CONFLICT (email) DO UPDATE SET name = excluded.name"
+	if query != expected {
+		t.Errorf("Expected upsert:\n%s\nGot:\n%s", expected, query)
+	}
+
+	_, _, err = newBuilder(nil, WithDialect(SQLite)).Table("users").
+		buildUpsertQuery(map[string]interface{}{"name": "John"}, nil)
+	if err == nil {
+		t.Error("Upsert without conflict columns should fail on sqlite")
+	}
+}
+
+func TestDialectCreateOrUpdate(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	data := map[string]interface{}{
+		"name":  "Johnny",
+		"email": "john@example.com",
+		"age":   40,
+	}
+
+	_, err := DB().Table("users").CreateOrUpdate(data, []string{"email"})
+	if err != nil {
+		t.Fatalf("CreateOrUpdate() failed: %v", err)
+	}
+
+	count, err := DB().Table("users").Where("name", "=", "Johnny").Where("age", "=", 40).Count()
+	if err != nil {
+		t.Fatalf("Count() failed: %v", err)
+	}
+
+	if count != 1 {
+		t.Errorf("Expected upserted row, got count %d", count)
+	}
+}
+
+func TestDialectPrintSQL(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{MySQL, `SELECT * FROM users WHERE name = 'O''Brien \\' AND active = 1`},
+		{Postgres, `SELECT * FROM users WHERE name = 'O''Brien \' AND active = TRUE`},
+		{SQLite, `SELECT * FROM users WHERE name = 'O''Brien \' AND active = 1`},
+		{SQLServer, `SELECT * FROM users WHERE name = N'O''Brien \' AND active = 1`},
+	}
+
+	for _, tt := range tests {
+		sql := newBuilder(nil, WithDialect(tt.dialect)).Table("users").
+			Where("name", "=", `O'Brien \`).
+			Where("active", "=", true).
+			PrintSQL()
+		if sql != tt.expected {
+			t.Errorf("[%s] Expected SQL:\n%s\nGot:\n%s", tt.dialect.Name(), tt.expected, sql)
+		}
+	}
+}
+
+func TestRebindSkipsLiterals(t *testing.T) {
+	query := rebind(Postgres, "SELECT '?' AS q, \"a?\" FROM t WHERE x = ? AND y = ?")
+	expected := "SELECT '?' AS q, \"a?\" FROM t WHERE x = $1 AND y = $2"
+	if query != expected {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expected, query)
+	}
+}
+
+func TestStrictIdentifiersQuoteAndAlias(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{MySQL, "SELECT `u`.`name` AS `author`, `u`.*, `posts`.`title` FROM `blog`.`users` AS `u` LEFT JOIN `posts` AS `p` ON p.user_id = u.id WHERE `u`.`age` > ? ORDER BY `u`.`name` DESC"},
+		{Postgres, `SELECT "u"."name" AS "author", "u".*, "posts"."title" FROM "blog"."users" AS "u" LEFT JOIN "posts" AS "p" ON p.user_id = u.id WHERE "u"."age" > $1 ORDER BY "u"."name" DESC`},
+		{SQLServer, "SELECT [u].[name] AS [author], [u].*, [posts].[title] FROM [blog].[users] AS [u] LEFT JOIN [posts] AS [p] ON p.user_id = u.id WHERE [u].[age] > @p1 ORDER BY [u].[name] DESC"},
+	}
+
+	for _, tt := range tests {
+		builder := newBuilder(nil, WithDialect(tt.dialect), WithStrictIdentifiers()).
+			Table("blog.users u").
+			Select("u.name AS author", "u.*", "posts.title").
+			LeftJoin("posts p", "p.user_id = u.id").
+			Where("u.age", ">", 18).
+			OrderBy("u.name", "DESC")
+
+		if builder.err != nil {
+			t.Fatalf("[%s] Unexpected error: %v", tt.dialect.Name(), builder.err)
+		}
+
+		query, _ := builder.buildSelectQuery()
+		query = rebind(tt.dialect, query)
+		if query != tt.expected {
+			t.Errorf("[%s] Expected query:\n%s\nGot:\n%s", tt.dialect.Name(), tt.expected, query)
+		}
+	}
+}
+
+func TestStrictIdentifiersRejectHostileNames(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	hostile := []string{
+		"id; DROP TABLE users",
+		"id; DROP TABLE users; --",
+		"name) OR (1=1",
+		"name = name --",
+		"users.name AS x; DELETE FROM users",
+		`"name"`,
+		"a.b.c.d",
+		"COUNT(*)",
+		"",
+	}
+
+	strict := func() *Builder {
+		return newBuilder(db, WithStrictIdentifiers()).Table("users")
+	}
+
+	for _, name := range hostile {
+		operations := map[string]func() error{
+			"Table": func() error {
+				_, err := newBuilder(db, WithStrictIdentifiers()).Table(name).Get()
+				return err
+			},
+			"Select": func() error {
+				_, err := strict().Select(name).Get()
+				return err
+			},
+			"Where": func() error {
+				_, err := strict().Where(name, "=", 1).Count()
+				return err
+			},
+			"OrderBy": func() error {
+				_, err := strict().OrderBy(name, "ASC").ToArray()
+				return err
+			},
+			"GroupBy": func() error {
+				_, err := strict().GroupBy(name).Get()
+				return err
+			},
+			"Insert": func() error {
+				_, err := strict().Insert(map[string]interface{}{name: "x"})
+				return err
+			},
+			"Update": func() error {
+				_, err := strict().Where("id", "=", 1).Update(map[string]interface{}{name: "x"})
+				return err
+			},
+			"UpdateBulk": func() error {
+				_, err := strict().UpdateBulk([]map[string]interface{}{{"id": 1, "age": 2}}, name)
+				return err
+			},
+			"Sum": func() error {
+				_, err := strict().Sum(name)
+				return err
+			},
+		}
+
+		for op, run := range operations {
+			if err := run(); !errors.Is(err, ErrInvalidIdentifier) {
+				t.Errorf("%s(%q): expected ErrInvalidIdentifier, got %v", op, name, err)
+			}
+		}
+	}
+
+	// Aliases are only accepted where SQL allows them
+	if _, err := strict().OrderBy("name desc", "ASC").Get(); !errors.Is(err, ErrInvalidIdentifier) {
+		t.Errorf("Expected alias in ORDER BY to be rejected, got %v", err)
+	}
+
+	count, err := DB().Table("users").Count()
+	if err != nil || count != 4 {
+		t.Fatalf("users table should be untouched, got count %d, err %v", count, err)
+	}
+}
+
+func TestStrictIdentifiersExecute(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	results, err := newBuilder(db, WithStrictIdentifiers()).
+		Table("users u").
+		Select("u.name AS author").
+		Where("u.age", ">", 28).
+		OrderBy("u.age", "ASC").
+		ToArray()
+	if err != nil {
+		t.Fatalf("ToArray() failed: %v", err)
+	}
+
+	if len(results) != 2 || results[0]["author"] != "Jane Smith" {
+		t.Errorf("Unexpected results: %v", results)
+	}
+}
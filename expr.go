@@ -0,0 +1,139 @@
+package gsorm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr is a raw SQL fragment with bound arguments, created with Raw.
+// Its "?" markers are bound to Args in order and rebound for the dialect
+// like every other placeholder, so values never end up in the SQL text.
+type Expr struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Raw creates an expression: gsorm.Raw("COALESCE(nickname, ?)", "anonymous")
+func Raw(sql string, args ...interface{}) Expr {
+	return Expr{SQL: sql, Args: args}
+}
+
+// SelectRaw adds an expression to the select list
+func (b *Builder) SelectRaw(sql string, args ...interface{}) *Builder {
+	expr := Raw(sql, args...)
+	if b.checkExpr(expr) {
+		b.selectCols = append(b.selectCols, expr.SQL)
+		b.selectArgs = append(b.selectArgs, expr.Args...)
+	}
+	return b
+}
+
+// WhereRaw adds a raw WHERE condition with AND logic
+func (b *Builder) WhereRaw(sql string, args ...interface{}) *Builder {
+	return b.whereRaw(sql, args, "AND")
+}
+
+// OrWhereRaw adds a raw WHERE condition with OR logic
+func (b *Builder) OrWhereRaw(sql string, args ...interface{}) *Builder {
+	return b.whereRaw(sql, args, "OR")
+}
+
+func (b *Builder) whereRaw(sql string, args []interface{}, logic string) *Builder {
+	expr := Raw(sql, args...)
+	if b.checkExpr(expr) {
+		b.whereConds = append(b.whereConds, WhereCondition{Value: expr, Logic: logic, kind: condRaw})
+	}
+	return b
+}
+
+// HavingRaw adds a raw HAVING condition with AND logic
+func (b *Builder) HavingRaw(sql string, args ...interface{}) *Builder {
+	return b.havingRaw(sql, args, "AND")
+}
+
+// OrHavingRaw adds a raw HAVING condition with OR logic
+func (b *Builder) OrHavingRaw(sql string, args ...interface{}) *Builder {
+	return b.havingRaw(sql, args, "OR")
+}
+
+func (b *Builder) havingRaw(sql string, args []interface{}, logic string) *Builder {
+	expr := Raw(sql, args...)
+	if b.checkExpr(expr) {
+		b.having = append(b.having, WhereCondition{Value: expr, Logic: logic, kind: condRaw})
+	}
+	return b
+}
+
+// OrderByRaw adds an ORDER BY expression, e.g. OrderByRaw("FIELD(status, ?, ?)", "paid", "pending")
+func (b *Builder) OrderByRaw(sql string, args ...interface{}) *Builder {
+	expr := Raw(sql, args...)
+	if b.checkExpr(expr) {
+		b.orderBy = append(b.orderBy, OrderCondition{Column: expr.SQL, Args: expr.Args})
+	}
+	return b
+}
+
+// GroupByRaw adds a GROUP BY expression
+func (b *Builder) GroupByRaw(sql string, args ...interface{}) *Builder {
+	expr := Raw(sql, args...)
+	if b.checkExpr(expr) {
+		b.groupBy = append(b.groupBy, expr.SQL)
+		b.groupArgs = append(b.groupArgs, expr.Args...)
+	}
+	return b
+}
+
+// LeftJoinRaw adds LEFT JOIN with a condition that binds args
+func (b *Builder) LeftJoinRaw(table, condition string, args ...interface{}) *Builder {
+	return b.joinRaw("LEFT", table, condition, args)
+}
+
+// RightJoinRaw adds RIGHT JOIN with a condition that binds args
+func (b *Builder) RightJoinRaw(table, condition string, args ...interface{}) *Builder {
+	return b.joinRaw("RIGHT", table, condition, args)
+}
+
+// InnerJoinRaw adds INNER JOIN with a condition that binds args
+func (b *Builder) InnerJoinRaw(table, condition string, args ...interface{}) *Builder {
+	return b.joinRaw("INNER", table, condition, args)
+}
+
+func (b *Builder) joinRaw(joinType, table, condition string, args []interface{}) *Builder {
+	expr := Raw(condition, args...)
+	if b.checkExpr(expr) {
+		b.joins = append(b.joins, JoinCondition{
+			Type:      joinType,
+			Table:     b.identAs(table),
+			Condition: expr.SQL,
+			Args:      expr.Args,
+		})
+	}
+	return b
+}
+
+// checkExpr records an error when the markers of e don't match its args
+func (b *Builder) checkExpr(e Expr) bool {
+	if n := countPlaceholders(e.SQL); n != len(e.Args) {
+		b.setErr(fmt.Errorf("gsorm: raw expression %q has %d placeholders but %d args", e.SQL, n, len(e.Args)))
+		return false
+	}
+	return true
+}
+
+// bindValue renders a written value: an Expr inline with its args,
+// anything else as a single "?" marker
+func (b *Builder) bindValue(value interface{}) (string, []interface{}) {
+	if expr, ok := value.(Expr); ok && b.checkExpr(expr) {
+		return expr.SQL, expr.Args
+	}
+	return "?", []interface{}{value}
+}
+
+// countPlaceholders counts the "?" markers outside of quoted literals
+func countPlaceholders(sql string) int {
+	n := 0
+	scanPlaceholders(sql, func(*strings.Builder, int) {
+		n++
+	})
+	return n
+}
@@ -0,0 +1,122 @@
+package gsorm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRawExpressionsMergeArgsInOrder(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	setupOrdersTable(t, db)
+
+	builder := DB().Table("users").
+		Select("users.name").
+		SelectRaw("COALESCE(email, ?) AS contact", "none").
+		LeftJoinRaw("orders", "orders.user_id = users.id AND orders.status = ?", "paid").
+		Where("age", ">", 20).
+		OrWhereRaw("name LIKE ? OR name LIKE ?", "A%", "B%").
+		GroupByRaw("users.id, users.name, email").
+		HavingRaw("COUNT(orders.id) >= ?", 0).
+		OrderByRaw("CASE WHEN name = ? THEN 0 ELSE 1 END", "Jane Smith").
+		OrderBy("name", "ASC")
+
+	query, args := builder.buildSelectQuery()
+
+	expectedQuery := "SELECT users.name, COALESCE(email, ?) AS contact FROM users " +
+		"LEFT JOIN orders ON orders.user_id = users.id AND orders.status = ? " +
+		"WHERE age > ? OR (name LIKE ? OR name LIKE ?) " +
+		"GROUP BY users.id, users.name, email HAVING (COUNT(orders.id) >= ?) " +
+		"ORDER BY CASE WHEN name = ? THEN 0 ELSE 1 END, name ASC"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	expectedArgs := []interface{}{"none", "paid", 20, "A%", "B%", 0, "Jane Smith"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, args)
+	}
+
+	results, err := builder.ToArray()
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 4 || results[0]["name"] != "Jane Smith" {
+		t.Errorf("Unexpected results: %v", results)
+	}
+}
+
+func TestRawExpressionAsValue(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	builder := DB().Table("users").Where("id", "=", 1)
+	query, args := builder.buildUpdateQuery(map[string]interface{}{
+		"age": Raw("age + ?", 5),
+	})
+
+	expectedQuery := "UPDATE users SET age = age + ? WHERE id = ?"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{5, 1}) {
+		t.Errorf("Expected args [5 1], got %v", args)
+	}
+
+	if _, err := DB().Table("users").Where("id", "=", 1).Update(map[string]interface{}{"age": Raw("age + ?", 5)}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	count, err := DB().Table("users").Where("age", "=", Raw("? * 3", 10)).Count()
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 users aged 30, got %d", count)
+	}
+}
+
+func TestRawExpressionRebind(t *testing.T) {
+	builder := newBuilder(nil, WithDialect(Postgres)).
+		Table("users").
+		Where("status", "=", "active").
+		OrderByRaw("array_position(?::text[], role)", "{admin,member}")
+
+	query, args := builder.buildSelectQuery()
+	query = rebind(builder.dialect, query)
+
+	expectedQuery := "SELECT * FROM users WHERE status = $1 ORDER BY array_position($2::text[], role)"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"active", "{admin,member}"}) {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
+
+func TestRawExpressionArgCountMismatch(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	builder := DB().Table("users").WhereRaw("age > ? AND name = ?", 20)
+
+	_, err := builder.Get()
+	if err == nil || !strings.Contains(err.Error(), "2 placeholders but 1 args") {
+		t.Errorf("Expected placeholder mismatch error, got %v", err)
+	}
+
+	// Quoted question marks are not placeholders
+	builder = DB().Table("users").WhereRaw("name <> '?' AND age > ?", 20)
+	if _, err := builder.Count(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// Errors inside groups reach the outer query
+	builder = DB().Table("users").WhereGroup(func(q *Builder) {
+		q.WhereRaw("age > ?")
+	})
+	if _, err := builder.Count(); err == nil {
+		t.Error("Expected error from grouped raw condition")
+	}
+}
@@ -1,11 +1,14 @@
 package gsorm
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
-	"time"
 )
 
 // Builder is the main ORM Builder structure
@@ -13,23 +16,90 @@ type Builder struct {
 	db         *sql.DB
 	table      string
 	selectCols []string
+	selectArgs []interface{}
+	fromSub    *Builder
+	ctes       []commonTable
+	compounds  []compoundQuery
 	whereConds []WhereCondition
 	joins      []JoinCondition
 	orderBy    []OrderCondition
 	groupBy    []string
+	groupArgs  []interface{}
 	having     []WhereCondition
 	limitVal   int
 	offsetVal  int
 	args       []interface{}
 	tx         *sql.Tx
+	ctx        context.Context
+	dialect    Dialect
+	quoting    bool
+	strict     bool
+	emptyIn    EmptyInPolicy
+	replicas   *replicaSet
+	onPrimary  bool
+	hooks      []Hook
+	stmts      *stmtCache
+	colOrder   []string
+	batchSize  int
+	missing    MissingColumnPolicy
+	returning  *returningSpec
+	upsert     upsertOptions
+	err        error
 }
 
-// WhereCondition stores safe WHERE conditions
+// Option configures a Builder when it is set up
+type Option func(*Builder)
+
+// WithDialect selects the SQL dialect instead of detecting it from the driver
+func WithDialect(d Dialect) Option {
+	return func(b *Builder) {
+		b.dialect = d
+	}
+}
+
+// WithIdentifierQuoting quotes plain identifiers (tables, columns) using the dialect
+func WithIdentifierQuoting() Option {
+	return func(b *Builder) {
+		b.quoting = true
+	}
+}
+
+// WithStrictIdentifiers validates every table and column name against
+// [schema.]table.column grammar and quotes it using the dialect. Invalid
+// names are never written into SQL; the query fails with ErrInvalidIdentifier.
+func WithStrictIdentifiers() Option {
+	return func(b *Builder) {
+		b.quoting = true
+		b.strict = true
+	}
+}
+
+// EmptyInPolicy decides what an IN condition with an empty list does
+type EmptyInPolicy int
+
+const (
+	// EmptyInError fails the query with ErrEmptyIn (the default)
+	EmptyInError EmptyInPolicy = iota
+	// EmptyInMatchNone makes IN () match no rows and NOT IN () match every row
+	EmptyInMatchNone
+)
+
+// WithEmptyIn sets the policy for IN conditions with an empty list
+func WithEmptyIn(policy EmptyInPolicy) Option {
+	return func(b *Builder) {
+		b.emptyIn = policy
+	}
+}
+
+// WhereCondition stores safe WHERE conditions.
+// A condition with a Group renders as a parenthesised sub-expression.
 type WhereCondition struct {
 	Column   string
 	Operator string
 	Value    interface{}
 	Logic    string // AND, OR
+	Group    []WhereCondition
+	kind     condKind
 }
 
 // JoinCondition stores JOIN conditions
@@ -37,17 +107,16 @@ type JoinCondition struct {
 	Type      string // LEFT, RIGHT, INNER
 	Table     string
 	Condition string
+	Args      []interface{} // bound by Condition (see LeftJoinRaw)
 }
 
 // OrderCondition stores ORDER BY conditions
 type OrderCondition struct {
 	Column string
-	Dir    string // ASC, DESC
+	Dir    string // ASC, DESC; empty for raw expressions
+	Args   []interface{}
 }
 
-var gsormOnce sync.Once
-var gsormInstance *Builder
-
 // Pool for string builders to reduce allocations
 var stringBuilderPool = sync.Pool{
 	New: func() interface{} {
@@ -67,87 +136,127 @@ func putStringBuilder(sb *strings.Builder) {
 	stringBuilderPool.Put(sb)
 }
 
-// Set initializes singleton instance (called only once)
-func Set(db *sql.DB) *Builder {
-	gsormOnce.Do(func() {
-		gsormInstance = &Builder{
-			db:         db,
-			selectCols: []string{"*"},
-			args:       make([]interface{}, 0),
-		}
-	})
+// newBuilder creates a root builder for db
+func newBuilder(db *sql.DB, opts ...Option) *Builder {
+	b := &Builder{
+		db:         db,
+		selectCols: []string{"*"},
+		args:       make([]interface{}, 0),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.dialect == nil {
+		b.dialect = detectDialect(db)
+	}
+	return b
+}
 
-	return gsormInstance
+// Dialect returns the SQL dialect used by the builder
+func (b *Builder) Dialect() Dialect {
+	return b.dialect
+}
+
+// WithContext sets the context used by every query the builder executes.
+// Cancelling ctx aborts in-flight queries and rolls back transactions begun with it.
+func (b *Builder) WithContext(ctx context.Context) *Builder {
+	b.ctx = ctx
+	return b
 }
 
-// DB returns the initialized singleton instance
-func DB() *Builder {
-	if gsormInstance == nil {
-		panic("GSORM not initialized. Call Set() first.")
+// Context returns the builder context, defaulting to context.Background()
+func (b *Builder) Context() context.Context {
+	if b.ctx == nil {
+		return context.Background()
 	}
-	// Return clone to avoid state sharing
-	return gsormInstance.Clone()
+	return b.ctx
 }
 
 // Table sets the target table
 func (b *Builder) Table(table string) *Builder {
-	b.table = table
+	b.table = b.identAs(table)
 	return b
 }
 
 // Select sets the columns to be selected
 func (b *Builder) Select(cols ...string) *Builder {
-	b.selectCols = cols
+	b.selectCols = make([]string, len(cols))
+	b.selectArgs = nil
+	for i, col := range cols {
+		b.selectCols[i] = b.identAs(col)
+	}
 	return b
 }
 
-// Where adds WHERE condition with prepared statements
+// Where adds WHERE condition with prepared statements.
+// operator must be one of the allowed operators (see ErrInvalidOperator).
 func (b *Builder) Where(column string, operator string, value interface{}) *Builder {
-	b.whereConds = append(b.whereConds, WhereCondition{
-		Column:   column,
-		Operator: operator,
-		Value:    value,
-		Logic:    "AND",
-	})
+	if cond, ok := b.condition(column, operator, value, "AND"); ok {
+		b.whereConds = append(b.whereConds, cond)
+	}
 	return b
 }
 
 // OrWhere adds WHERE condition with OR logic
 func (b *Builder) OrWhere(column string, operator string, value interface{}) *Builder {
-	b.whereConds = append(b.whereConds, WhereCondition{
-		Column:   column,
-		Operator: operator,
-		Value:    value,
-		Logic:    "OR",
-	})
+	if cond, ok := b.condition(column, operator, value, "OR"); ok {
+		b.whereConds = append(b.whereConds, cond)
+	}
 	return b
 }
 
-// WhereIn adds safe WHERE IN condition
+// WhereIn adds safe WHERE IN condition. An empty list is handled by
+// the EmptyInPolicy of the builder.
 func (b *Builder) WhereIn(column string, values []interface{}) *Builder {
-	if len(values) > 0 {
-		placeholders := make([]string, len(values))
-		for i := range values {
-			placeholders[i] = "?"
-		}
+	return b.Where(column, "IN", values)
+}
+
+// WhereGroup adds a parenthesised group of conditions built by fn
+func (b *Builder) WhereGroup(fn func(*Builder)) *Builder {
+	return b.whereGroup(fn, "AND")
+}
+
+// OrWhereGroup adds a parenthesised group of conditions with OR logic
+func (b *Builder) OrWhereGroup(fn func(*Builder)) *Builder {
+	return b.whereGroup(fn, "OR")
+}
+
+func (b *Builder) whereGroup(fn func(*Builder), logic string) *Builder {
+	scope := b.scope()
+	fn(scope)
+	if scope.err != nil {
+		b.setErr(scope.err)
+	}
 
+	if len(scope.whereConds) > 0 {
 		b.whereConds = append(b.whereConds, WhereCondition{
-			Column:   column,
-			Operator: "IN (" + strings.Join(placeholders, ",") + ")",
-			Value:    values,
-			Logic:    "AND",
+			Logic: logic,
+			Group: scope.whereConds,
 		})
 	}
 	return b
 }
 
+// scope returns an empty builder sharing b's settings, used to collect
+// the conditions of a group
+func (b *Builder) scope() *Builder {
+	return &Builder{
+		db:      b.db,
+		dialect: b.dialect,
+		quoting: b.quoting,
+		strict:  b.strict,
+		emptyIn: b.emptyIn,
+	}
+}
+
 // WhereNotNull adds WHERE column IS NOT NULL condition
 func (b *Builder) WhereNotNull(column string) *Builder {
 	b.whereConds = append(b.whereConds, WhereCondition{
-		Column:   column,
+		Column:   b.ident(column),
 		Operator: "IS NOT NULL",
 		Value:    nil,
 		Logic:    "AND",
+		kind:     condNull,
 	})
 	return b
 }
@@ -155,10 +264,11 @@ func (b *Builder) WhereNotNull(column string) *Builder {
 // WhereNull adds WHERE column IS NULL condition
 func (b *Builder) WhereNull(column string) *Builder {
 	b.whereConds = append(b.whereConds, WhereCondition{
-		Column:   column,
+		Column:   b.ident(column),
 		Operator: "IS NULL",
 		Value:    nil,
 		Logic:    "AND",
+		kind:     condNull,
 	})
 	return b
 }
@@ -167,7 +277,7 @@ func (b *Builder) WhereNull(column string) *Builder {
 func (b *Builder) LeftJoin(table, condition string) *Builder {
 	b.joins = append(b.joins, JoinCondition{
 		Type:      "LEFT",
-		Table:     table,
+		Table:     b.identAs(table),
 		Condition: condition,
 	})
 	return b
@@ -177,7 +287,7 @@ func (b *Builder) LeftJoin(table, condition string) *Builder {
 func (b *Builder) RightJoin(table, condition string) *Builder {
 	b.joins = append(b.joins, JoinCondition{
 		Type:      "RIGHT",
-		Table:     table,
+		Table:     b.identAs(table),
 		Condition: condition,
 	})
 	return b
@@ -187,7 +297,7 @@ func (b *Builder) RightJoin(table, condition string) *Builder {
 func (b *Builder) InnerJoin(table, condition string) *Builder {
 	b.joins = append(b.joins, JoinCondition{
 		Type:      "INNER",
-		Table:     table,
+		Table:     b.identAs(table),
 		Condition: condition,
 	})
 	return b
@@ -196,13 +306,14 @@ func (b *Builder) InnerJoin(table, condition string) *Builder {
 // OrderBy adds ORDER BY clause
 func (b *Builder) OrderBy(column, direction string) *Builder {
 	// Validate direction to prevent injection
-	dir := strings.ToUpper(direction)
-	if dir != "ASC" && dir != "DESC" {
-		dir = "ASC"
+	dir, err := orderDirection(direction)
+	if err != nil {
+		b.setErr(err)
+		return b
 	}
 
 	b.orderBy = append(b.orderBy, OrderCondition{
-		Column: column,
+		Column: b.ident(column),
 		Dir:    dir,
 	})
 	return b
@@ -210,40 +321,80 @@ func (b *Builder) OrderBy(column, direction string) *Builder {
 
 // GroupBy adds GROUP BY clause
 func (b *Builder) GroupBy(columns ...string) *Builder {
-	b.groupBy = append(b.groupBy, columns...)
+	for _, col := range columns {
+		b.groupBy = append(b.groupBy, b.ident(col))
+	}
 	return b
 }
 
 // Having adds HAVING condition
 func (b *Builder) Having(column string, operator string, value interface{}) *Builder {
-	b.having = append(b.having, WhereCondition{
-		Column:   column,
-		Operator: operator,
-		Value:    value,
-		Logic:    "AND",
-	})
+	if cond, ok := b.condition(column, operator, value, "AND"); ok {
+		b.having = append(b.having, cond)
+	}
+	return b
+}
+
+// OrHaving adds HAVING condition with OR logic
+func (b *Builder) OrHaving(column string, operator string, value interface{}) *Builder {
+	if cond, ok := b.condition(column, operator, value, "OR"); ok {
+		b.having = append(b.having, cond)
+	}
+	return b
+}
+
+// HavingGroup adds a parenthesised group of HAVING conditions; fn adds
+// them with Having and OrHaving
+func (b *Builder) HavingGroup(fn func(*Builder)) *Builder {
+	return b.havingGroup(fn, "AND")
+}
+
+// OrHavingGroup adds a parenthesised group of HAVING conditions with OR logic
+func (b *Builder) OrHavingGroup(fn func(*Builder)) *Builder {
+	return b.havingGroup(fn, "OR")
+}
+
+func (b *Builder) havingGroup(fn func(*Builder), logic string) *Builder {
+	scope := b.scope()
+	fn(scope)
+	if scope.err != nil {
+		b.setErr(scope.err)
+	}
+
+	if len(scope.having) > 0 {
+		b.having = append(b.having, WhereCondition{
+			Logic: logic,
+			Group: scope.having,
+		})
+	}
 	return b
 }
 
 // Limit sets the LIMIT clause
 func (b *Builder) Limit(limit int) *Builder {
+	if limit < 0 {
+		b.setErr(fmt.Errorf("gsorm: negative limit %d", limit))
+		return b
+	}
 	b.limitVal = limit
 	return b
 }
 
 // Offset sets the OFFSET clause for pagination
 func (b *Builder) Offset(offset int) *Builder {
+	if offset < 0 {
+		b.setErr(fmt.Errorf("gsorm: negative offset %d", offset))
+		return b
+	}
 	b.offsetVal = offset
 	return b
 }
 
-// Paginate sets up pagination
+// Paginate sets up pagination; page starts at 1
 func (b *Builder) Paginate(page, perPage int) *Builder {
-	if page < 1 {
-		page = 1
-	}
-	if perPage < 1 {
-		perPage = 10
+	if page < 1 || perPage < 1 {
+		b.setErr(fmt.Errorf("gsorm: invalid pagination page %d, per page %d", page, perPage))
+		return b
 	}
 
 	b.limitVal = perPage
@@ -258,10 +409,26 @@ func (b *Builder) buildSelectQuery() (string, []interface{}) {
 	
 	args := make([]interface{}, 0, 4) // Pre-allocate for common case
 	
+	// WITH clause
+	if len(b.ctes) > 0 {
+		withClause, withArgs := b.buildWithClause()
+		query.WriteString(withClause)
+		args = append(args, withArgs...)
+	}
+
 	// SELECT clause
 	query.WriteString("SELECT ")
 	query.WriteString(strings.Join(b.selectCols, ", "))
+	args = append(args, b.selectArgs...)
 	query.WriteString(" FROM ")
+
+	if b.fromSub != nil {
+		subQuery, subArgs := b.fromSub.buildSelectQuery()
+		query.WriteString("(")
+		query.WriteString(subQuery)
+		query.WriteString(") AS ")
+		args = append(args, subArgs...)
+	}
 	query.WriteString(b.table)
 
 	// JOIN clauses
@@ -272,6 +439,7 @@ func (b *Builder) buildSelectQuery() (string, []interface{}) {
 		query.WriteString(join.Table)
 		query.WriteString(" ON ")
 		query.WriteString(join.Condition)
+		args = append(args, join.Args...)
 	}
 
 	// WHERE clauses
@@ -286,6 +454,7 @@ func (b *Builder) buildSelectQuery() (string, []interface{}) {
 	if len(b.groupBy) > 0 {
 		query.WriteString(" GROUP BY ")
 		query.WriteString(strings.Join(b.groupBy, ", "))
+		args = append(args, b.groupArgs...)
 	}
 
 	// HAVING
@@ -296,6 +465,20 @@ func (b *Builder) buildSelectQuery() (string, []interface{}) {
 		args = append(args, havingArgs...)
 	}
 
+	// UNION / INTERSECT / EXCEPT
+	for _, part := range b.compounds {
+		partQuery, partArgs := part.query.buildSelectQuery()
+		query.WriteString(" ")
+		query.WriteString(part.operator)
+		query.WriteString(" ")
+		if part.query.paginated() {
+			// Keep the branch's own ORDER BY/LIMIT inside the branch
+			partQuery = "SELECT * FROM (" + partQuery + ") AS t"
+		}
+		query.WriteString(partQuery)
+		args = append(args, partArgs...)
+	}
+
 	// ORDER BY
 	if len(b.orderBy) > 0 {
 		query.WriteString(" ORDER BY ")
@@ -304,21 +487,18 @@ func (b *Builder) buildSelectQuery() (string, []interface{}) {
 				query.WriteString(", ")
 			}
 			query.WriteString(order.Column)
-			query.WriteString(" ")
-			query.WriteString(order.Dir)
+			if order.Dir != "" {
+				query.WriteString(" ")
+				query.WriteString(order.Dir)
+			}
+			args = append(args, order.Args...)
 		}
 	}
 
 	// LIMIT and OFFSET
-	if b.limitVal > 0 {
-		query.WriteString(" LIMIT ?")
-		args = append(args, b.limitVal)
-	}
-
-	if b.offsetVal > 0 {
-		query.WriteString(" OFFSET ?")
-		args = append(args, b.offsetVal)
-	}
+	limitClause, limitArgs := b.dialect.LimitOffset(b.limitVal, b.offsetVal, len(b.orderBy) > 0)
+	query.WriteString(limitClause)
+	args = append(args, limitArgs...)
 
 	return query.String(), args
 }
@@ -341,158 +521,686 @@ func (b *Builder) buildWhereClause(conditions []WhereCondition) (string, []inter
 			clause.WriteString(" ")
 		}
 
-		clause.WriteString(cond.Column)
-		clause.WriteString(" ")
+		if len(cond.Group) > 0 {
+			groupClause, groupArgs := b.buildWhereClause(cond.Group)
+			clause.WriteString("(")
+			clause.WriteString(groupClause)
+			clause.WriteString(")")
+			args = append(args, groupArgs...)
+			continue
+		}
+
+		if cond.kind == condList && len(cond.Value.([]interface{})) == 0 {
+			// Empty list under EmptyInMatchNone
+			if cond.Operator == "NOT IN" {
+				clause.WriteString("1 = 1")
+			} else {
+				clause.WriteString("1 = 0")
+			}
+			continue
+		}
+
+		if cond.kind == condRaw {
+			// Raw condition, parenthesised so its own OR binds tighter
+			raw := cond.Value.(Expr)
+			clause.WriteString("(")
+			clause.WriteString(raw.SQL)
+			clause.WriteString(")")
+			args = append(args, raw.Args...)
+			continue
+		}
+
+		if cond.Column != "" {
+			clause.WriteString(cond.Column)
+			clause.WriteString(" ")
+		}
 		clause.WriteString(cond.Operator)
 
-		if cond.Operator == "IS NULL" || cond.Operator == "IS NOT NULL" {
+		switch cond.kind {
+		case condNull:
 			// No value needed
-		} else if strings.Contains(cond.Operator, "IN") {
-			if values, ok := cond.Value.([]interface{}); ok {
-				args = append(args, values...)
-			}
-		} else {
-			clause.WriteString(" ?")
-			args = append(args, cond.Value)
+		case condList:
+			values := cond.Value.([]interface{})
+			clause.WriteString(" (")
+			clause.WriteString(strings.TrimSuffix(strings.Repeat("?,", len(values)), ","))
+			clause.WriteString(")")
+			args = append(args, b.tagArgs(cond.Column, values)...)
+		case condBetween:
+			values := cond.Value.([]interface{})
+			clause.WriteString(" ? AND ?")
+			args = append(args, b.tagArgs(cond.Column, values)...)
+		case condSub:
+			subQuery, subArgs := cond.Value.(*Builder).buildSelectQuery()
+			clause.WriteString(" (")
+			clause.WriteString(subQuery)
+			clause.WriteString(")")
+			args = append(args, subArgs...)
+		case condColumn:
+			clause.WriteString(" ")
+			clause.WriteString(string(cond.Value.(columnRef)))
+		default:
+			value, valueArgs := b.bindColumn(cond.Column, cond.Value)
+			clause.WriteString(" ")
+			clause.WriteString(value)
+			args = append(args, valueArgs...)
 		}
 	}
 
 	return clause.String(), args
 }
 
+// ident quotes a plain identifier when quoting is enabled; expressions and
+// aliased columns are written as given. In strict mode anything else is
+// rejected.
+func (b *Builder) ident(name string) string {
+	if b.strict {
+		return b.strictIdent(name, false)
+	}
+	if !b.quoting || !identPattern.MatchString(name) {
+		return name
+	}
+	return quoteIdent(b.dialect, name)
+}
+
+// identAs is ident for tables and select columns, which may carry an alias
+// ("users u", "users.name AS author")
+func (b *Builder) identAs(name string) string {
+	if b.strict {
+		return b.strictIdent(name, true)
+	}
+	return b.ident(name)
+}
+
+// strictIdent validates name and quotes each part, recording
+// ErrInvalidIdentifier when it doesn't match the grammar
+func (b *Builder) strictIdent(name string, alias bool) string {
+	m := strictIdentPattern.FindStringSubmatch(name)
+	if m == nil || (m[2] != "" && (!alias || strings.HasSuffix(m[1], "*") || strings.EqualFold(m[2], "AS"))) {
+		b.setErr(fmt.Errorf("%w: %q", ErrInvalidIdentifier, name))
+		return name
+	}
+
+	quoted := quoteIdent(b.dialect, m[1])
+	if m[2] != "" {
+		quoted += " AS " + b.dialect.QuoteIdent(m[2])
+	}
+	return quoted
+}
+
+// ErrInvalidIdentifier is returned in strict identifier mode when a table
+// or column name is not a plain [schema.]table.column identifier
+var ErrInvalidIdentifier = errors.New("gsorm: invalid identifier")
+
+// identPattern matches plain, optionally dotted identifiers
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*(\.\*)?$`)
+
+// strictIdentPattern matches "*", or up to three dotted identifier parts
+// (optionally ending in ".*"), followed by an optional [AS] alias
+var strictIdentPattern = regexp.MustCompile(
+	`^(\*|[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*){0,2}(?:\.\*)?)` +
+		`(?:\s+(?:[Aa][Ss]\s+)?([A-Za-z_][A-Za-z0-9_]*))?$`)
+
+// Err returns the first error recorded while building the query, such as
+// an invalid operator or identifier. Execution methods return it without
+// touching the database.
+func (b *Builder) Err() error {
+	return b.err
+}
+
+// setErr records the first error found while building the query
+func (b *Builder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// inherit records the error of a builder embedded in b (subquery, CTE, ...)
+func (b *Builder) inherit(sub *Builder) {
+	if sub != nil && sub.err != nil {
+		b.setErr(sub.err)
+	}
+}
+
+// orderDirection normalises an ORDER BY direction; empty means ASC
+func orderDirection(direction string) (string, error) {
+	dir := strings.ToUpper(strings.TrimSpace(direction))
+	switch dir {
+	case "":
+		return "ASC", nil
+	case "ASC", "DESC":
+		return dir, nil
+	}
+	return "", fmt.Errorf("gsorm: invalid ORDER BY direction %q", direction)
+}
+
+// exec runs a write on the active transaction or the primary connection
+func (b *Builder) exec(kind QueryKind, query string, args []interface{}) (sql.Result, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.returning != nil {
+		return b.execReturning(kind, query, args)
+	}
+
+	var result sql.Result
+	err := b.run(b.Context(), kind, rebind(b.dialect, query), args, func(ctx context.Context, query string, args []interface{}) (int64, error) {
+		var err error
+		result, err = b.execContext(ctx, b.db, query, args)
+		if err != nil {
+			return -1, err
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			return n, nil
+		}
+		return -1, nil
+	})
+	return result, err
+}
+
+// query runs a read on the active transaction, or a replica when configured
+func (b *Builder) query(query string, args []interface{}) (*sql.Rows, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	var rows *sql.Rows
+	err := b.run(b.Context(), KindSelect, rebind(b.dialect, query), args, func(ctx context.Context, query string, args []interface{}) (int64, error) {
+		var err error
+		if b.tx != nil {
+			rows, err = b.queryContext(ctx, b.db, query, args)
+		} else {
+			rows, err = onReplica(b, func(db *sql.DB) (*sql.Rows, error) {
+				return b.queryContext(ctx, db, query, args)
+			})
+		}
+		return -1, err
+	})
+	return rows, err
+}
+
+// queryRow runs a read returning a single row. Errors are only known on
+// Scan, so a failing replica is not retried and hooks only report their
+// own Before errors here.
+func (b *Builder) queryRow(query string, args []interface{}) (*sql.Row, error) {
+	var row *sql.Row
+	err := b.run(b.Context(), KindSelect, rebind(b.dialect, query), args, func(ctx context.Context, query string, args []interface{}) (int64, error) {
+		db := b.db
+		if b.tx == nil {
+			db = b.readDB()
+		}
+		row = b.queryRowContext(ctx, db, query, args)
+		return -1, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// scanRow runs a single row read and scans it into dest
+func (b *Builder) scanRow(query string, args []interface{}, dest ...interface{}) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	return b.run(b.Context(), KindSelect, rebind(b.dialect, query), args, func(ctx context.Context, query string, args []interface{}) (int64, error) {
+		if b.tx != nil {
+			return -1, b.queryRowContext(ctx, b.db, query, args).Scan(dest...)
+		}
+		_, err := onReplica(b, func(db *sql.DB) (struct{}, error) {
+			return struct{}{}, b.queryRowContext(ctx, db, query, args).Scan(dest...)
+		})
+		return -1, err
+	})
+}
+
 // Get retrieves all records
 func (b *Builder) Get() (*sql.Rows, error) {
 	query, args := b.buildSelectQuery()
-
-	if b.tx != nil {
-		return b.tx.Query(query, args...)
-	}
-	return b.db.Query(query, args...)
+	return b.query(query, args)
 }
 
 // First retrieves the first record
 func (b *Builder) First() (*sql.Row, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
 	b.limitVal = 1
 	query, args := b.buildSelectQuery()
-
-	if b.tx != nil {
-		return b.tx.QueryRow(query, args...), nil
-	}
-	return b.db.QueryRow(query, args...), nil
+	return b.queryRow(query, args)
 }
 
 // Count counts the number of records
 func (b *Builder) Count() (int64, error) {
-	originalCols := b.selectCols
-	b.selectCols = []string{"COUNT(*) as count"}
-
-	query, args := b.buildSelectQuery()
-	b.selectCols = originalCols
+	query, args := b.buildAggregateQuery("COUNT(*) as count")
 
 	var count int64
-	var row *sql.Row
+	err := b.scanRow(query, args, &count)
+	return count, err
+}
 
-	if b.tx != nil {
-		row = b.tx.QueryRow(query, args...)
-	} else {
-		row = b.db.QueryRow(query, args...)
+// ColumnOrder sets the order of the columns written by Insert, InsertBulk,
+// Update, UpdateBulk and CreateOrUpdate. Listed columns come first in the
+// given order; the remaining map keys follow sorted by name, which is also
+// the default order.
+func (b *Builder) ColumnOrder(cols ...string) *Builder {
+	b.colOrder = cols
+	return b
+}
+
+// orderedColumns returns the keys of data in the builder's column order
+func (b *Builder) orderedColumns(data map[string]interface{}) []string {
+	columns := make([]string, 0, len(data))
+	seen := make(map[string]bool, len(b.colOrder))
+	for _, col := range b.colOrder {
+		if _, ok := data[col]; ok && !seen[col] {
+			columns = append(columns, col)
+			seen[col] = true
+		}
 	}
 
-	err := row.Scan(&count)
-	return count, err
+	rest := len(columns)
+	for col := range data {
+		if !seen[col] {
+			columns = append(columns, col)
+		}
+	}
+	sort.Strings(columns[rest:])
+	return columns
 }
 
-// Insert performs INSERT with prepared statement
-func (b *Builder) Insert(data map[string]interface{}) (sql.Result, error) {
+// buildInsertQuery builds INSERT statement for a single row
+func (b *Builder) buildInsertQuery(data map[string]interface{}) (string, []interface{}) {
 	columns := make([]string, 0, len(data))
-	placeholders := make([]string, 0, len(data))
+	markers := make([]string, 0, len(data))
 	values := make([]interface{}, 0, len(data))
 
-	for col, val := range data {
-		columns = append(columns, col)
-		placeholders = append(placeholders, "?")
-		values = append(values, val)
+	for _, col := range b.orderedColumns(data) {
+		quoted := b.ident(col)
+		marker, args := b.bindColumn(quoted, data[col])
+		columns = append(columns, quoted)
+		markers = append(markers, marker)
+		values = append(values, args...)
 	}
 
-	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+	output, returning := b.returningClause(KindInsert)
+	query := fmt.Sprintf("INSERT INTO %s (%s)%s VALUES (%s)%s",
 		b.table,
 		strings.Join(columns, ", "),
-		strings.Join(placeholders, ", "))
+		output,
+		strings.Join(markers, ", "),
+		returning)
+
+	return query, values
+}
+
+// Insert performs INSERT with prepared statement
+func (b *Builder) Insert(data map[string]interface{}) (sql.Result, error) {
+	query, values := b.buildInsertQuery(data)
+	return b.exec(KindInsert, query, values)
+}
+
+// BatchSize caps the rows per statement of InsertBulk, InsertColumns,
+// UpsertBulk and UpdateBulk. Without it (or with n <= 0) batches are as large as the
+// dialect's parameter limit allows.
+func (b *Builder) BatchSize(n int) *Builder {
+	b.batchSize = n
+	return b
+}
 
-	if b.tx != nil {
-		return b.tx.Exec(query, values...)
+// insertBatch accumulates the value rows of one multi-row statement
+type insertBatch struct {
+	values strings.Builder // "(?, ?), (?, ?)"
+	args   []interface{}
+	rows   int
+}
+
+// add appends a row given as its markers and bound args
+func (ib *insertBatch) add(markers []string, args []interface{}) {
+	if ib.rows > 0 {
+		ib.values.WriteString(", ")
+	}
+	ib.values.WriteString("(")
+	for j, marker := range markers {
+		if j > 0 {
+			ib.values.WriteString(", ")
+		}
+		ib.values.WriteString(marker)
 	}
-	return b.db.Exec(query, values...)
+	ib.values.WriteString(")")
+	ib.args = append(ib.args, args...)
+	ib.rows++
 }
 
-// InsertBulk performs efficient bulk insert
-func (b *Builder) InsertBulk(data []map[string]interface{}) error {
-	if len(data) == 0 {
+// insertHeader returns "INSERT INTO table (cols) VALUES "
+func (b *Builder) insertHeader(columns []string) string {
+	header := getStringBuilder()
+	defer putStringBuilder(header)
+
+	header.WriteString("INSERT INTO ")
+	header.WriteString(b.table)
+	header.WriteString(" (")
+	for i, col := range columns {
+		if i > 0 {
+			header.WriteString(", ")
+		}
+		header.WriteString(b.ident(col))
+	}
+	header.WriteString(")")
+	output, _ := b.returningClause(KindInsert)
+	header.WriteString(output)
+	header.WriteString(" VALUES ")
+	return header.String()
+}
+
+// bindRow binds the values of row i into markers and returns its args.
+// quoted holds the quoted columns the values are written to.
+func (b *Builder) bindRow(i int, quoted, markers []string, args []interface{}, value func(i, j int) interface{}) []interface{} {
+	args = args[:0]
+	for j := range markers {
+		marker, valueArgs := b.bindColumn(quoted[j], value(i, j))
+		markers[j] = marker
+		args = append(args, valueArgs...)
+	}
+	return args
+}
+
+// quotedColumns returns columns quoted with ident
+func (b *Builder) quotedColumns(columns []string) []string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = b.ident(col)
+	}
+	return quoted
+}
+
+// buildInsertBulkQuery builds a single multi-row INSERT statement
+func (b *Builder) buildInsertBulkQuery(data []map[string]interface{}) (string, []interface{}) {
+	columns, _ := b.bulkColumns(data)
+	header := b.insertHeader(columns)
+	quoted := b.quotedColumns(columns)
+	markers := make([]string, len(columns))
+
+	var batch insertBatch
+	batch.args = make([]interface{}, 0, len(data)*len(columns))
+	var rowArgs []interface{}
+	for i := range data {
+		rowArgs = b.bindRow(i, quoted, markers, rowArgs, func(i, j int) interface{} {
+			return data[i][columns[j]]
+		})
+		batch.add(markers, rowArgs)
+	}
+	_, returning := b.returningClause(KindInsert)
+	return header + batch.values.String() + returning, batch.args
+}
+
+// insertRows inserts numRows rows, taking the value of row i, column j from
+// value. It returns the number of rows inserted.
+func (b *Builder) insertRows(columns []string, numRows int, value func(i, j int) interface{}) (int64, error) {
+	header := b.insertHeader(columns)
+	_, returning := b.returningClause(KindInsert)
+	return b.writeRows(KindInsert, columns, numRows, 0, value, func(batch *insertBatch) (string, []interface{}, error) {
+		return header + batch.values.String() + returning, batch.args, nil
+	})
+}
+
+// writeRows writes numRows rows with statements of kind that statement
+// renders from a batch of value rows, leaving reserved parameters for args
+// it adds. Rows are split into statements that stay within BatchSize and
+// the dialect's parameter limit; several statements run in one transaction
+// unless one is already active. It returns the number of rows affected.
+func (b *Builder) writeRows(kind QueryKind, columns []string, numRows, reserved int, value func(i, j int) interface{}, statement func(*insertBatch) (string, []interface{}, error)) (int64, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("gsorm: %s requires at least one column", kind)
+	}
+
+	maxParams := b.dialect.MaxParams() - reserved
+	quoted := b.quotedColumns(columns)
+	markers := make([]string, len(columns))
+	perBatch := maxParams / len(columns)
+	if maxRows := b.dialect.MaxRows(); maxRows > 0 && maxRows < perBatch {
+		perBatch = maxRows
+	}
+	if b.batchSize > 0 && b.batchSize < perBatch {
+		perBatch = b.batchSize
+	}
+
+	target := b // runs the statements; a transaction clone once begun here
+	var total int64
+	var batch insertBatch
+	var rowArgs []interface{}
+
+	flush := func() error {
+		query, args, err := statement(&batch)
+		if err != nil {
+			return err
+		}
+		result, err := target.exec(kind, query, args)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err == nil {
+			total += n
+		}
+		batch = insertBatch{}
 		return nil
 	}
 
-	// Get columns from first data row
-	firstRow := data[0]
-	numCols := len(firstRow)
-	columns := make([]string, 0, numCols)
-	for col := range firstRow {
-		columns = append(columns, col)
+	run := func() error {
+		for i := 0; i < numRows; i++ {
+			rowArgs = b.bindRow(i, quoted, markers, rowArgs, value)
+			if len(rowArgs) > maxParams {
+				return fmt.Errorf("gsorm: row %d binds %d parameters, more than the %s limit of %d",
+					i, len(rowArgs), b.dialect.Name(), maxParams)
+			}
+
+			if batch.rows > 0 && (batch.rows >= perBatch || len(batch.args)+len(rowArgs) > maxParams) {
+				if target == b && b.tx == nil {
+					// More than one statement: run them all in one transaction
+					target = b.Clone()
+					if err := target.BeginTransaction(); err != nil {
+						target = b
+						return err
+					}
+				}
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+
+			if batch.rows == 0 {
+				batch.args = make([]interface{}, 0, min(numRows-i, perBatch)*len(columns))
+			}
+			batch.add(markers, rowArgs)
+		}
+		return flush()
 	}
 
-	// Pre-allocate with exact capacity
-	numRows := len(data)
-	allValues := make([]interface{}, 0, numRows*numCols)
-	
-	// Use string builder from pool
-	query := getStringBuilder()
-	defer putStringBuilder(query)
-	
-	// Build query efficiently
-	query.WriteString("INSERT INTO ")
-	query.WriteString(b.table)
-	query.WriteString(" (")
-	query.WriteString(strings.Join(columns, ", "))
-	query.WriteString(") VALUES ")
-	
-	// Build VALUES clause
-	for i, row := range data {
-		if i > 0 {
-			query.WriteString(", ")
+	err := run()
+	if target != b {
+		if err != nil {
+			target.RollbackTransaction()
+			return 0, err
 		}
-		query.WriteString("(")
-		
-		// Add placeholders and values
-		for j, col := range columns {
-			if j > 0 {
-				query.WriteString(", ")
+		if err := target.CommitTransaction(); err != nil {
+			return 0, err
+		}
+	}
+	return total, err
+}
+
+// MissingColumnPolicy decides what InsertBulk writes for a column that
+// some rows have and others don't
+type MissingColumnPolicy int
+
+const (
+	// MissingNull writes NULL for missing columns (the default)
+	MissingNull MissingColumnPolicy = iota
+	// MissingDefault lets the database fill missing columns with their
+	// default. Dialects without DEFAULT in VALUES insert rows with different
+	// columns in separate statements.
+	MissingDefault
+	// MissingError fails the insert when a row lacks a column of another row
+	MissingError
+)
+
+// defaultValue renders the DEFAULT keyword as a value
+var defaultValue = Expr{SQL: "DEFAULT"}
+
+// MissingColumns sets how InsertBulk handles rows that lack some columns
+func (b *Builder) MissingColumns(policy MissingColumnPolicy) *Builder {
+	b.missing = policy
+	return b
+}
+
+// bulkColumns returns the union of the columns of all rows, in column order,
+// and whether every row has all of them
+func (b *Builder) bulkColumns(data []map[string]interface{}) ([]string, bool) {
+	union := make(map[string]interface{}, len(data[0]))
+	for col := range data[0] {
+		union[col] = nil
+	}
+
+	uniform := true
+	for _, row := range data[1:] {
+		if len(row) != len(union) {
+			uniform = false
+		}
+		for col := range row {
+			if _, ok := union[col]; !ok {
+				union[col] = nil
+				uniform = false
+			}
+		}
+	}
+	return b.orderedColumns(union), uniform
+}
+
+// InsertBulk inserts rows in as few statements as the dialect's parameter
+// limit and BatchSize allow. The columns are the union of the keys of all
+// rows; the MissingColumns policy decides what is written for a row without
+// one of them. It returns the number of rows inserted.
+func (b *Builder) InsertBulk(data []map[string]interface{}) (int64, error) {
+	if len(data) == 0 {
+		return 0, b.err
+	}
+
+	columns, uniform := b.bulkColumns(data)
+	if !uniform {
+		switch {
+		case b.missing == MissingError:
+			for i, row := range data {
+				for _, col := range columns {
+					if _, ok := row[col]; !ok {
+						return 0, fmt.Errorf("gsorm: InsertBulk row %d has no value for column %q", i, col)
+					}
+				}
 			}
-			query.WriteString("?")
-			allValues = append(allValues, row[col])
+		case b.missing == MissingDefault && !b.dialect.DefaultValues():
+			return b.insertShapes(data)
 		}
-		query.WriteString(")")
 	}
 
-	queryStr := query.String()
+	missing := interface{}(nil)
+	if b.missing == MissingDefault {
+		missing = defaultValue
+	}
+	return b.insertRows(columns, len(data), func(i, j int) interface{} {
+		if value, ok := data[i][columns[j]]; ok {
+			return value
+		}
+		return missing
+	})
+}
+
+// insertShapes inserts rows grouped by their set of columns, so columns a
+// row lacks are left to their defaults. Groups are inserted in order of
+// first appearance, in one transaction unless one is already active.
+func (b *Builder) insertShapes(data []map[string]interface{}) (int64, error) {
+	var shapes []string
+	groups := make(map[string][]map[string]interface{})
+	for _, row := range data {
+		cols := b.orderedColumns(row)
+		shape := strings.Join(cols, "\x00")
+		if _, ok := groups[shape]; !ok {
+			shapes = append(shapes, shape)
+		}
+		groups[shape] = append(groups[shape], row)
+	}
 
-	if b.tx != nil {
-		_, err := b.tx.Exec(queryStr, allValues...)
+	insert := func(target *Builder) (int64, error) {
+		var total int64
+		for _, shape := range shapes {
+			rows := groups[shape]
+			columns := b.orderedColumns(rows[0])
+			n, err := target.insertRows(columns, len(rows), func(i, j int) interface{} {
+				return rows[i][columns[j]]
+			})
+			total += n
+			if err != nil {
+				return total, err
+			}
+		}
+		return total, nil
+	}
+
+	if b.tx != nil || len(shapes) == 1 {
+		return insert(b)
+	}
+
+	var total int64
+	err := b.Clone().WithTransaction(func(tx *Builder) error {
+		var err error
+		total, err = insert(tx)
 		return err
+	})
+	if err != nil {
+		return 0, err
 	}
-	_, err := b.db.Exec(queryStr, allValues...)
-	return err
+	return total, nil
 }
 
-// Update performs UPDATE with WHERE conditions
-func (b *Builder) Update(data map[string]interface{}) (sql.Result, error) {
+// InsertColumns inserts rows given as value lists in the order of cols,
+// avoiding a map per row. Every row must have one value per column.
+// Rows are batched like InsertBulk.
+func (b *Builder) InsertColumns(cols []string, rows [][]interface{}) (int64, error) {
+	if len(rows) == 0 {
+		return 0, b.err
+	}
+	for i, row := range rows {
+		if len(row) != len(cols) {
+			return 0, fmt.Errorf("gsorm: InsertColumns row %d has %d values, expected %d", i, len(row), len(cols))
+		}
+	}
+
+	return b.insertRows(cols, len(rows), func(i, j int) interface{} {
+		return rows[i][j]
+	})
+}
+
+// buildUpdateQuery builds UPDATE statement with WHERE conditions
+func (b *Builder) buildUpdateQuery(data map[string]interface{}) (string, []interface{}) {
 	setClauses := make([]string, 0, len(data))
 	args := make([]interface{}, 0, len(data))
+	query := ""
 
-	for col, val := range data {
-		setClauses = append(setClauses, col+" = ?")
-		args = append(args, val)
+	if len(b.ctes) > 0 {
+		query, args = b.buildWithClause()
 	}
 
-	query := "UPDATE " + b.table + " SET " + strings.Join(setClauses, ", ")
+	for _, col := range b.orderedColumns(data) {
+		quoted := b.ident(col)
+		value, valueArgs := b.bindColumn(quoted, data[col])
+		setClauses = append(setClauses, quoted+" = "+value)
+		args = append(args, valueArgs...)
+	}
+
+	output, returning := b.returningClause(KindUpdate)
+	query += "UPDATE " + b.table + " SET " + strings.Join(setClauses, ", ") + output
 
 	if len(b.whereConds) > 0 {
 		whereClause, whereArgs := b.buildWhereClause(b.whereConds)
@@ -500,92 +1208,254 @@ func (b *Builder) Update(data map[string]interface{}) (sql.Result, error) {
 		args = append(args, whereArgs...)
 	}
 
-	if b.tx != nil {
-		return b.tx.Exec(query, args...)
-	}
-	return b.db.Exec(query, args...)
+	return query + returning, args
 }
 
-// UpdateBulk performs efficient bulk update
-func (b *Builder) UpdateBulk(updates []map[string]interface{}, keyColumn string) error {
-	if len(updates) == 0 {
-		return nil
+// Update performs UPDATE with WHERE conditions
+func (b *Builder) Update(data map[string]interface{}) (sql.Result, error) {
+	query, args := b.buildUpdateQuery(data)
+	return b.exec(KindUpdate, query, args)
+}
+
+// buildUpdateBulkQuery builds one CASE WHEN based UPDATE statement for all
+// updates. Each column is set only for the rows that have it, and only rows
+// with a column to set are matched.
+func (b *Builder) buildUpdateBulkQuery(updates []map[string]interface{}, keyColumns ...string) (string, []interface{}) {
+	query := ""
+	args := make([]interface{}, 0)
+
+	if len(b.ctes) > 0 {
+		query, args = b.buildWithClause()
 	}
 
-	// CASE WHEN implementation for bulk update
-	columns := make(map[string]bool)
+	set := make(map[string]interface{})
+	matched := make([]map[string]interface{}, 0, len(updates))
 	for _, update := range updates {
 		for col := range update {
-			if col != keyColumn {
-				columns[col] = true
+			if !containsString(keyColumns, col) {
+				set[col] = nil
 			}
 		}
+		if len(update) > len(keyColumns) {
+			matched = append(matched, update)
+		}
+	}
+	columns := b.orderedColumns(set)
+
+	// A single key uses the simple CASE form, composite keys a condition
+	quotedKeys := make([]string, len(keyColumns))
+	keys := make([]string, len(keyColumns))
+	for i, col := range keyColumns {
+		quotedKeys[i] = b.ident(col)
+		keys[i] = quotedKeys[i] + " = ?"
+	}
+	keyMatch := strings.Join(keys, " AND ")
+	keyArgs := func(update map[string]interface{}) {
+		for i, col := range keyColumns {
+			args = append(args, b.tagArg(quotedKeys[i], update[col]))
+		}
 	}
 
-	setClauses := make([]string, 0)
-	args := make([]interface{}, 0)
-	keyValues := make([]interface{}, len(updates))
-
-	for col := range columns {
-		caseClause := col + " = CASE " + keyColumn
-		for _, update := range updates {
-			caseClause += " WHEN ? THEN ?"
-			args = append(args, update[keyColumn], update[col])
+	sets := getStringBuilder()
+	defer putStringBuilder(sets)
+	for i, col := range columns {
+		quoted := b.ident(col)
+		if i > 0 {
+			sets.WriteString(", ")
+		}
+		sets.WriteString(quoted)
+		sets.WriteString(" = CASE")
+		if len(keyColumns) == 1 {
+			sets.WriteString(" " + b.ident(keyColumns[0]))
+		}
+		for _, update := range matched {
+			value, ok := update[col]
+			if !ok {
+				continue
+			}
+			if len(keyColumns) == 1 {
+				sets.WriteString(" WHEN ? THEN ")
+			} else {
+				sets.WriteString(" WHEN " + keyMatch + " THEN ")
+			}
+			keyArgs(update)
+			marker, valueArgs := b.bindColumn(quoted, value)
+			sets.WriteString(marker)
+			args = append(args, valueArgs...)
 		}
-		caseClause += " ELSE " + col + " END"
-		setClauses = append(setClauses, caseClause)
+		sets.WriteString(" ELSE " + quoted + " END")
 	}
 
-	for i, update := range updates {
-		keyValues[i] = update[keyColumn]
+	output, returning := b.returningClause(KindUpdate)
+	query += "UPDATE " + b.table + " SET " + sets.String() + output + " WHERE "
+
+	if len(b.whereConds) > 0 {
+		whereClause, whereArgs := b.buildWhereClause(b.whereConds)
+		query += "(" + whereClause + ") AND "
+		args = append(args, whereArgs...)
 	}
 
-	// Create IN clause for WHERE
-	inPlaceholders := make([]string, len(keyValues))
-	for i := range keyValues {
-		inPlaceholders[i] = "?"
+	if len(keyColumns) == 1 {
+		query += b.ident(keyColumns[0]) + " IN (" + placeholderList(len(matched)) + ")"
+	} else {
+		query += "(" + strings.TrimSuffix(strings.Repeat("("+keyMatch+") OR ", len(matched)), " OR ") + ")"
+	}
+	for _, update := range matched {
+		keyArgs(update)
 	}
 
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s IN (%s)",
-		b.table,
-		strings.Join(setClauses, ", "),
-		keyColumn,
-		strings.Join(inPlaceholders, ", "))
+	return query + returning, args
+}
+
+// UpdateBulk updates many rows, matching each by the values of its
+// keyColumns. Columns a row lacks are left unchanged for that row, and the
+// builder's Where conditions further restrict the rows updated. Rows are
+// split into statements that stay within BatchSize and the dialect's
+// parameter limit; several statements run in one transaction unless one is
+// already active. It returns the number of rows affected.
+func (b *Builder) UpdateBulk(updates []map[string]interface{}, keyColumns ...string) (int64, error) {
+	if len(updates) == 0 {
+		return 0, b.err
+	}
+	if len(keyColumns) == 0 {
+		return 0, fmt.Errorf("gsorm: UpdateBulk requires at least one key column")
+	}
+	for _, col := range keyColumns {
+		b.ident(col)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	// Parameters every statement binds besides its rows
+	reserved := 0
+	if len(b.ctes) > 0 {
+		_, args := b.buildWithClause()
+		reserved += len(args)
+	}
+	if len(b.whereConds) > 0 {
+		_, args := b.buildWhereClause(b.whereConds)
+		reserved += len(args)
+	}
+	maxParams := b.dialect.MaxParams() - reserved
+
+	var batches [][]map[string]interface{}
+	var batch []map[string]interface{}
+	params := 0
+	for i, update := range updates {
+		for _, col := range keyColumns {
+			if _, ok := update[col]; !ok {
+				return 0, fmt.Errorf("gsorm: UpdateBulk row %d has no value for key column %q", i, col)
+			}
+		}
+		if len(update) == len(keyColumns) {
+			continue // nothing to update
+		}
 
-	args = append(args, keyValues...)
+		// The keys once in WHERE, and once per column in its CASE
+		n := len(keyColumns)
+		for col, value := range update {
+			if containsString(keyColumns, col) {
+				continue
+			}
+			n += len(keyColumns) + 1
+			if expr, ok := value.(Expr); ok {
+				n += len(expr.Args) - 1
+			}
+		}
+		if n > maxParams {
+			return 0, fmt.Errorf("gsorm: row %d binds %d parameters, more than the %s limit of %d",
+				i, n, b.dialect.Name(), maxParams)
+		}
 
-	if b.tx != nil {
-		_, err := b.tx.Exec(query, args...)
+		if len(batch) > 0 && ((b.batchSize > 0 && len(batch) >= b.batchSize) || params+n > maxParams) {
+			batches = append(batches, batch)
+			batch, params = nil, 0
+		}
+		batch = append(batch, update)
+		params += n
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	batches = append(batches, batch)
+
+	update := func(target *Builder) (int64, error) {
+		var total int64
+		for _, batch := range batches {
+			query, args := target.buildUpdateBulkQuery(batch, keyColumns...)
+			result, err := target.exec(KindUpdate, query, args)
+			if err != nil {
+				return total, err
+			}
+			if n, err := result.RowsAffected(); err == nil {
+				total += n
+			}
+		}
+		return total, nil
+	}
+
+	if b.tx != nil || len(batches) == 1 {
+		return update(b)
+	}
+
+	var total int64
+	err := b.Clone().WithTransaction(func(tx *Builder) error {
+		var err error
+		total, err = update(tx)
 		return err
+	})
+	if err != nil {
+		return 0, err
 	}
-	_, err := b.db.Exec(query, args...)
-	return err
+	return total, nil
 }
 
-// Delete performs DELETE with WHERE conditions
-func (b *Builder) Delete() (sql.Result, error) {
-	query := "DELETE FROM " + b.table
+// buildDeleteQuery builds DELETE statement with WHERE conditions
+func (b *Builder) buildDeleteQuery() (string, []interface{}) {
+	query := ""
 	args := make([]interface{}, 0)
 
+	if len(b.ctes) > 0 {
+		query, args = b.buildWithClause()
+	}
+	output, returning := b.returningClause(KindDelete)
+	query += "DELETE FROM " + b.table + output
+
 	if len(b.whereConds) > 0 {
 		whereClause, whereArgs := b.buildWhereClause(b.whereConds)
 		query += " WHERE " + whereClause
 		args = append(args, whereArgs...)
 	}
 
-	if b.tx != nil {
-		return b.tx.Exec(query, args...)
-	}
-	return b.db.Exec(query, args...)
+	return query + returning, args
+}
+
+// Delete performs DELETE with WHERE conditions
+func (b *Builder) Delete() (sql.Result, error) {
+	query, args := b.buildDeleteQuery()
+	return b.exec(KindDelete, query, args)
 }
 
 // Transaction methods
 func (b *Builder) BeginTransaction() error {
-	tx, err := b.db.Begin()
+	return b.BeginTx(b.Context(), nil)
+}
+
+// BeginTx starts a transaction bound to ctx with the given options.
+// The transaction is rolled back by database/sql if ctx is cancelled.
+func (b *Builder) BeginTx(ctx context.Context, opts *sql.TxOptions) error {
+	var tx *sql.Tx
+	err := b.run(ctx, KindBegin, "BEGIN", nil, func(ctx context.Context, _ string, _ []interface{}) (int64, error) {
+		var err error
+		tx, err = b.db.BeginTx(ctx, opts)
+		return -1, err
+	})
 	if err != nil {
 		return err
 	}
 	b.tx = tx
+	b.ctx = ctx
 	return nil
 }
 
@@ -593,18 +1463,22 @@ func (b *Builder) CommitTransaction() error {
 	if b.tx == nil {
 		return fmt.Errorf("no active transaction")
 	}
-	err := b.tx.Commit()
+	tx := b.tx
 	b.tx = nil
-	return err
+	return b.run(b.Context(), KindCommit, "COMMIT", nil, func(context.Context, string, []interface{}) (int64, error) {
+		return -1, tx.Commit()
+	})
 }
 
 func (b *Builder) RollbackTransaction() error {
 	if b.tx == nil {
 		return fmt.Errorf("no active transaction")
 	}
-	err := b.tx.Rollback()
+	tx := b.tx
 	b.tx = nil
-	return err
+	return b.run(b.Context(), KindRollback, "ROLLBACK", nil, func(context.Context, string, []interface{}) (int64, error) {
+		return -1, tx.Rollback()
+	})
 }
 
 // WithTransaction runs operations within transaction context
@@ -621,89 +1495,77 @@ func (b *Builder) WithTransaction(fn func(*Builder) error) error {
 	return b.CommitTransaction()
 }
 
-// CreateOrUpdate performs UPSERT operation
-func (b *Builder) CreateOrUpdate(data map[string]interface{}, conflictColumns []string) (sql.Result, error) {
-	// MySQL implementation using ON DUPLICATE KEY UPDATE
-	columns := make([]string, 0, len(data))
-	placeholders := make([]string, 0, len(data))
-	values := make([]interface{}, 0, len(data))
-	updateClauses := make([]string, 0)
-
-	for col, val := range data {
-		columns = append(columns, col)
-		placeholders = append(placeholders, "?")
-		values = append(values, val)
-
-		// Skip conflict columns in update clause
-		isConflictCol := false
-		for _, conflictCol := range conflictColumns {
-			if col == conflictCol {
-				isConflictCol = true
-				break
-			}
-		}
-		if !isConflictCol {
-			updateClauses = append(updateClauses, col+" = VALUES("+col+")")
-		}
+// buildUpsertQuery builds dialect specific UPSERT statement
+func (b *Builder) buildUpsertQuery(data map[string]interface{}, conflictColumns []string) (string, []interface{}, error) {
+	columns := b.orderedColumns(data)
+	spec, whereArgs, err := b.upsertSpec(columns, conflictColumns, nil)
+	if err != nil {
+		return "", nil, err
 	}
 
-	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
-		b.table,
-		strings.Join(columns, ", "),
-		strings.Join(placeholders, ", "),
-		strings.Join(updateClauses, ", "))
+	markers := make([]string, len(columns))
+	values := make([]interface{}, 0, len(columns)+len(whereArgs))
+	for i, col := range columns {
+		marker, valueArgs := b.bindColumn(spec.Columns[i], data[col])
+		markers[i] = marker
+		values = append(values, valueArgs...)
+	}
+	values = append(values, whereArgs...)
+	spec.Values = "(" + strings.Join(markers, ", ") + ")"
 
-	if b.tx != nil {
-		return b.tx.Exec(query, values...)
+	query, err := b.dialect.Upsert(spec)
+	return query, values, err
+}
+
+// CreateOrUpdate performs UPSERT operation
+func (b *Builder) CreateOrUpdate(data map[string]interface{}, conflictColumns []string) (sql.Result, error) {
+	query, values, err := b.buildUpsertQuery(data, conflictColumns)
+	if err != nil {
+		return nil, err
 	}
-	return b.db.Exec(query, values...)
+	return b.exec(KindUpsert, query, values)
 }
 
 // PrintSQL for debugging - displays the SQL to be executed
 func (b *Builder) PrintSQL() string {
 	query, args := b.buildSelectQuery()
+	args, _ = splitArgs(args)
 
 	// Replace placeholders with values for debugging
-	for i, arg := range args {
-		placeholder := "?"
-		var value string
-
-		switch v := arg.(type) {
-		case string:
-			value = "'" + strings.Replace(v, "'", "''", -1) + "'"
-		case time.Time:
-			value = "'" + v.Format("2006-01-02 15:04:05") + "'"
-		case nil:
-			value = "NULL"
-		default:
-			value = fmt.Sprintf("%v", v)
-		}
+	return interpolate(b.dialect, query, args)
+}
 
-		if i == 0 {
-			query = strings.Replace(query, placeholder, value, 1)
-		} else {
-			query = strings.Replace(query, placeholder, value, 1)
+// buildAggregateQuery builds a SELECT of a single aggregate expression over
+// the current query. Compound queries are wrapped in a derived table so the
+// aggregate covers the combined result.
+func (b *Builder) buildAggregateQuery(expr string) (string, []interface{}) {
+	if len(b.compounds) > 0 {
+		inner := b
+		if b.limitVal <= 0 && b.offsetVal <= 0 && len(b.orderBy) > 0 {
+			// ORDER BY without LIMIT is meaningless in a derived table
+			inner = b.Clone()
+			inner.orderBy = nil
 		}
+
+		innerQuery, args := inner.buildSelectQuery()
+		return "SELECT " + expr + " FROM (" + innerQuery + ") AS compound", args
 	}
 
-	return query
+	originalCols, originalArgs := b.selectCols, b.selectArgs
+	b.selectCols, b.selectArgs = []string{expr}, nil
+
+	query, args := b.buildSelectQuery()
+	b.selectCols, b.selectArgs = originalCols, originalArgs
+
+	return query, args
 }
 
 // Aggregate functions
 func (b *Builder) Sum(column string) (float64, error) {
-	b.selectCols = []string{"SUM(" + column + ") as sum"}
-	query, args := b.buildSelectQuery()
+	query, args := b.buildAggregateQuery("SUM(" + b.ident(column) + ") as sum")
 
 	var sum sql.NullFloat64
-	var row *sql.Row
-
-	if b.tx != nil {
-		row = b.tx.QueryRow(query, args...)
-	} else {
-		row = b.db.QueryRow(query, args...)
-	}
-
-	err := row.Scan(&sum)
+	err := b.scanRow(query, args, &sum)
 	if err != nil {
 		return 0, err
 	}
@@ -715,53 +1577,26 @@ func (b *Builder) Sum(column string) (float64, error) {
 }
 
 func (b *Builder) Max(column string) (interface{}, error) {
-	b.selectCols = []string{"MAX(" + column + ") as max"}
-	query, args := b.buildSelectQuery()
+	query, args := b.buildAggregateQuery("MAX(" + b.ident(column) + ") as max")
 
 	var max interface{}
-	var row *sql.Row
-
-	if b.tx != nil {
-		row = b.tx.QueryRow(query, args...)
-	} else {
-		row = b.db.QueryRow(query, args...)
-	}
-
-	err := row.Scan(&max)
+	err := b.scanRow(query, args, &max)
 	return max, err
 }
 
 func (b *Builder) Min(column string) (interface{}, error) {
-	b.selectCols = []string{"MIN(" + column + ") as min"}
-	query, args := b.buildSelectQuery()
+	query, args := b.buildAggregateQuery("MIN(" + b.ident(column) + ") as min")
 
 	var min interface{}
-	var row *sql.Row
-
-	if b.tx != nil {
-		row = b.tx.QueryRow(query, args...)
-	} else {
-		row = b.db.QueryRow(query, args...)
-	}
-
-	err := row.Scan(&min)
+	err := b.scanRow(query, args, &min)
 	return min, err
 }
 
 func (b *Builder) Avg(column string) (float64, error) {
-	b.selectCols = []string{"AVG(" + column + ") as avg"}
-	query, args := b.buildSelectQuery()
+	query, args := b.buildAggregateQuery("AVG(" + b.ident(column) + ") as avg")
 
 	var avg sql.NullFloat64
-	var row *sql.Row
-
-	if b.tx != nil {
-		row = b.tx.QueryRow(query, args...)
-	} else {
-		row = b.db.QueryRow(query, args...)
-	}
-
-	err := row.Scan(&avg)
+	err := b.scanRow(query, args, &avg)
 	if err != nil {
 		return 0, err
 	}
@@ -780,6 +1615,11 @@ func (b *Builder) ToArray() ([]map[string]interface{}, error) {
 	}
 	defer rows.Close()
 
+	return scanMaps(rows)
+}
+
+// scanMaps reads every row of rows into a map keyed by column name
+func scanMaps(rows *sql.Rows) ([]map[string]interface{}, error) {
 	columns, err := rows.Columns()
 	if err != nil {
 		return nil, err
@@ -807,7 +1647,7 @@ func (b *Builder) ToArray() ([]map[string]interface{}, error) {
 		results = append(results, row)
 	}
 
-	return results, nil
+	return results, rows.Err()
 }
 
 // Clone creates a copy of builder for reuse
@@ -818,6 +1658,22 @@ func (b *Builder) Clone() *Builder {
 		limitVal:  b.limitVal,
 		offsetVal: b.offsetVal,
 		tx:        b.tx,
+		ctx:       b.ctx,
+		fromSub:   b.fromSub,
+		dialect:   b.dialect,
+		quoting:   b.quoting,
+		strict:    b.strict,
+		emptyIn:   b.emptyIn,
+		replicas:  b.replicas,
+		onPrimary: b.onPrimary,
+		hooks:     b.hooks,
+		stmts:     b.stmts,
+		colOrder:  b.colOrder,
+		batchSize: b.batchSize,
+		missing:   b.missing,
+		returning: b.returning,
+		upsert:    b.upsert,
+		err:       b.err,
 	}
 
 	// Only allocate slices if they have content
@@ -828,9 +1684,23 @@ func (b *Builder) Clone() *Builder {
 		clone.selectCols = []string{"*"}
 	}
 
+	if len(b.ctes) > 0 {
+		clone.ctes = make([]commonTable, len(b.ctes))
+		copy(clone.ctes, b.ctes)
+	}
+
+	if len(b.compounds) > 0 {
+		clone.compounds = make([]compoundQuery, len(b.compounds))
+		copy(clone.compounds, b.compounds)
+	}
+
+	if len(b.selectArgs) > 0 {
+		clone.selectArgs = make([]interface{}, len(b.selectArgs))
+		copy(clone.selectArgs, b.selectArgs)
+	}
+
 	if len(b.whereConds) > 0 {
-		clone.whereConds = make([]WhereCondition, len(b.whereConds))
-		copy(clone.whereConds, b.whereConds)
+		clone.whereConds = cloneConditions(b.whereConds)
 	}
 
 	if len(b.joins) > 0 {
@@ -848,9 +1718,13 @@ func (b *Builder) Clone() *Builder {
 		copy(clone.groupBy, b.groupBy)
 	}
 
+	if len(b.groupArgs) > 0 {
+		clone.groupArgs = make([]interface{}, len(b.groupArgs))
+		copy(clone.groupArgs, b.groupArgs)
+	}
+
 	if len(b.having) > 0 {
-		clone.having = make([]WhereCondition, len(b.having))
-		copy(clone.having, b.having)
+		clone.having = cloneConditions(b.having)
 	}
 
 	if len(b.args) > 0 {
@@ -862,3 +1736,16 @@ func (b *Builder) Clone() *Builder {
 
 	return clone
 }
+
+// cloneConditions deep-copies conditions including nested groups
+func cloneConditions(conds []WhereCondition) []WhereCondition {
+	cloned := make([]WhereCondition, len(conds))
+	copy(cloned, conds)
+
+	for i := range cloned {
+		if len(cloned[i].Group) > 0 {
+			cloned[i].Group = cloneConditions(cloned[i].Group)
+		}
+	}
+	return cloned
+}
@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"fmt"
 	"math/rand"
-	"sync"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -12,8 +11,7 @@ import (
 
 func setupBenchDB(b *testing.B) *sql.DB {
 	// Reset singleton for each benchmark
-	gsormInstance = nil
-	gsormOnce = sync.Once{}
+	resetSingleton()
 
 	db, err := sql.Open("sqlite3", ":memory:")
 	if err != nil {
@@ -57,7 +55,7 @@ func setupBenchDB(b *testing.B) *sql.DB {
 		}
 	}
 
-	err = DB().Table("users").InsertBulk(data)
+	_, err = DB().Table("users").InsertBulk(data)
 	if err != nil {
 		b.Fatalf("Failed to insert bench data: %v", err)
 	}
@@ -140,6 +138,22 @@ func BenchmarkSelectWithWhere(b *testing.B) {
 	}
 }
 
+func BenchmarkSelectWithWhereStatementCache(b *testing.B) {
+	db := setupBenchDB(b)
+	defer db.Close()
+	root := New(db, WithStatementCache(64))
+	defer root.CloseStatements()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rows, err := root.Clone().Table("users").Where("age", ">", 30).Get()
+		if err != nil {
+			b.Fatal(err)
+		}
+		rows.Close()
+	}
+}
+
 func BenchmarkSelectWithMultipleWhere(b *testing.B) {
 	db := setupBenchDB(b)
 	defer db.Close()
@@ -310,6 +324,28 @@ func BenchmarkInsert(b *testing.B) {
 	}
 }
 
+func BenchmarkInsertStatementCache(b *testing.B) {
+	db := setupBenchDB(b)
+	defer db.Close()
+	root := New(db, WithStatementCache(64))
+	defer root.CloseStatements()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data := map[string]interface{}{
+			"name":          fmt.Sprintf("BenchUser_%d", i),
+			"email":         fmt.Sprintf("bench%d@example.com", i),
+			"age":           25 + (i % 40),
+			"salary":        40000.0 + float64(i*50),
+			"department_id": (i % 5) + 1,
+		}
+		_, err := root.Clone().Table("users").Insert(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkInsertBulk(b *testing.B) {
 	db := setupBenchDB(b)
 	defer db.Close()
@@ -330,7 +366,7 @@ func BenchmarkInsertBulk(b *testing.B) {
 				"department_id": (idx % 5) + 1,
 			}
 		}
-		err := DB().Table("users").InsertBulk(data)
+		_, err := DB().Table("users").InsertBulk(data)
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -371,7 +407,7 @@ func BenchmarkUpdateBulk(b *testing.B) {
 				"salary": 50000.0 + float64(idx*20),
 			}
 		}
-		err := DB().Table("users").UpdateBulk(updates, "id")
+		_, err := DB().Table("users").UpdateBulk(updates, "id")
 		if err != nil {
 			b.Fatal(err)
 		}
@@ -394,7 +430,7 @@ func BenchmarkDelete(b *testing.B) {
 			"department_id": 1,
 		}
 	}
-	err := DB().Table("users").InsertBulk(data)
+	_, err := DB().Table("users").InsertBulk(data)
 	if err != nil {
 		b.Fatal(err)
 	}
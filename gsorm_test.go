@@ -1,17 +1,23 @@
 package gsorm
 
 import (
+	"context"
 	"database/sql"
-	"sync"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// resetSingleton resets the singleton for testing
+// resetSingleton clears the default connection (and any named ones)
 func resetSingleton() {
-	gsormInstance = nil
-	gsormOnce = sync.Once{}
+	registryMu.Lock()
+	registry = make(map[string]*Builder)
+	registryMu.Unlock()
 }
 
 func setupTestDB(t *testing.T) *sql.DB {
@@ -82,7 +88,7 @@ func TestDB(t *testing.T) {
 
 func TestDBPanic(t *testing.T) {
 	// Reset singleton for test
-	gsormInstance = nil
+	resetSingleton()
 	defer func() {
 		if r := recover(); r == nil {
 			t.Error("DB() should panic when not initialized")
@@ -165,9 +171,14 @@ func TestWhereIn(t *testing.T) {
 	}
 
 	cond := builder.whereConds[0]
-	if cond.Column != "age" || cond.Operator != "IN (?,?,?)" {
+	if cond.Column != "age" || cond.Operator != "IN" || cond.kind != condList {
 		t.Errorf("WhereIn condition not set correctly: %+v", cond)
 	}
+
+	query, args := builder.buildWhereClause(builder.whereConds)
+	if query != "age IN (?,?,?)" || len(args) != 3 {
+		t.Errorf("WhereIn clause not built correctly: %s %v", query, args)
+	}
 }
 
 func TestWhereNull(t *testing.T) {
@@ -253,8 +264,16 @@ func TestOrderByInvalidDirection(t *testing.T) {
 	Set(db)
 	builder := DB().OrderBy("name", "INVALID")
 
-	if builder.orderBy[0].Dir != "ASC" {
-		t.Errorf("Expected default direction 'ASC', got '%s'", builder.orderBy[0].Dir)
+	if len(builder.orderBy) != 0 {
+		t.Errorf("Invalid direction should not add an ORDER BY, got %+v", builder.orderBy)
+	}
+
+	if builder.Err() == nil {
+		t.Error("Expected an error for invalid direction")
+	}
+
+	if builder := DB().OrderBy("name", ""); builder.Err() != nil || builder.orderBy[0].Dir != "ASC" {
+		t.Errorf("Empty direction should default to ASC, got %+v (%v)", builder.orderBy, builder.Err())
 	}
 }
 
@@ -334,14 +353,14 @@ func TestPaginateInvalidValues(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	builder := Set(db).Paginate(0, -5)
+	builder := DB().Paginate(0, -5)
 
-	if builder.limitVal != 10 {
-		t.Errorf("Expected default limit 10, got %d", builder.limitVal)
+	if builder.limitVal != 0 || builder.offsetVal != 0 {
+		t.Errorf("Invalid pagination should not be applied, got limit %d offset %d", builder.limitVal, builder.offsetVal)
 	}
 
-	if builder.offsetVal != 0 {
-		t.Errorf("Expected offset 0, got %d", builder.offsetVal)
+	if _, err := builder.ToArray(); err == nil || err != builder.Err() {
+		t.Errorf("Expected ToArray to return the pagination error, got %v", err)
 	}
 }
 
@@ -471,10 +490,13 @@ func TestInsertBulk(t *testing.T) {
 		{"name": "User3", "email": "user3@example.com", "age": 22},
 	}
 
-	err := Set(db).Table("users").InsertBulk(data)
+	inserted, err := Set(db).Table("users").InsertBulk(data)
 	if err != nil {
 		t.Fatalf("InsertBulk() failed: %v", err)
 	}
+	if inserted != 3 {
+		t.Errorf("Expected 3 rows inserted, got %d", inserted)
+	}
 
 	count, err := Set(db).Table("users").Count()
 	if err != nil {
@@ -486,16 +508,385 @@ func TestInsertBulk(t *testing.T) {
 	}
 }
 
+// smallParams is SQLite with a tiny parameter limit, to exercise batching
+type smallParams struct{ Dialect }
+
+func (smallParams) MaxParams() int { return 4 }
+
+func TestInsertBulkBatches(t *testing.T) {
+	var calls []string
+	hook := &recordHook{name: "rec", calls: &calls}
+	root := New(openNamedDB(t), WithHooks(hook))
+
+	rows := make([]map[string]interface{}, 5)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"name": fmt.Sprintf("item-%d", i)}
+	}
+
+	inserted, err := root.Clone().Table("items").BatchSize(2).InsertBulk(rows)
+	if err != nil || inserted != 5 {
+		t.Fatalf("Expected 5 rows inserted, got %d (%v)", inserted, err)
+	}
+
+	var kinds []QueryKind
+	for _, event := range hook.events {
+		kinds = append(kinds, event.Kind)
+	}
+	expected := []QueryKind{KindBegin, KindInsert, KindInsert, KindInsert, KindCommit}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("Expected %v, got %v", expected, kinds)
+	}
+	if sql := hook.events[3].SQL; sql != "INSERT INTO items (name) VALUES (?)" {
+		t.Errorf("Unexpected last batch: %s", sql)
+	}
+
+	// A single batch needs no transaction
+	hook.events = nil
+	if _, err := root.Clone().Table("items").InsertBulk(rows[:2]); err != nil {
+		t.Fatalf("InsertBulk() failed: %v", err)
+	}
+	if len(hook.events) != 1 || hook.events[0].Kind != KindInsert {
+		t.Errorf("Expected a single insert, got %v", hook.events)
+	}
+}
+
+func TestInsertBulkSQLServerRowLimit(t *testing.T) {
+	var calls []string
+	hook := &recordHook{name: "rec", calls: &calls}
+	root := New(openNamedDB(t), WithDialect(SQLServer), WithHooks(hook, denyInsertHook{}))
+
+	rows := make([]map[string]interface{}, 1500)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"name": fmt.Sprintf("item-%d", i)}
+	}
+	root.Clone().Table("items").InsertBulk(rows)
+
+	// The denied first insert stops the batches: only its size matters
+	var inserts []QueryEvent
+	for _, event := range hook.events {
+		if event.Kind == KindInsert {
+			inserts = append(inserts, event)
+		}
+	}
+	if len(inserts) != 1 || len(inserts[0].Args) != 1000 {
+		t.Errorf("Expected a first statement of 1000 rows, got %v", inserts)
+	}
+}
+
+func TestInsertBulkParamLimit(t *testing.T) {
+	var calls []string
+	hook := &recordHook{name: "rec", calls: &calls}
+	root := New(openNamedDB(t), WithDialect(smallParams{SQLite}), WithHooks(hook))
+
+	// Raw values bind several parameters and count against the limit
+	inserted, err := root.Clone().Table("items").InsertColumns([]string{"id", "name"}, [][]interface{}{
+		{1, "a"},
+		{2, "b"},
+		{3, Raw("? || ?", "c", "d")},
+		{4, "e"},
+	})
+	if err != nil || inserted != 4 {
+		t.Fatalf("Expected 4 rows inserted, got %d (%v)", inserted, err)
+	}
+
+	var sizes []int
+	for _, event := range hook.events {
+		if event.Kind == KindInsert {
+			sizes = append(sizes, len(event.Args))
+		}
+	}
+	if !reflect.DeepEqual(sizes, []int{4, 3, 2}) {
+		t.Errorf("Expected batches of 4, 3 and 2 params, got %v", sizes)
+	}
+
+	_, err = root.Clone().Table("items").InsertColumns([]string{"id", "name"}, [][]interface{}{
+		{5, Raw("? || ? || ? || ?", "a", "b", "c", "d")},
+	})
+	if err == nil {
+		t.Error("Expected error for a row over the parameter limit")
+	}
+}
+
+func TestInsertBulkRollsBackOnError(t *testing.T) {
+	root := New(openNamedDB(t, "existing"))
+
+	_, err := root.Clone().Table("items").BatchSize(1).InsertColumns([]string{"id", "name"}, [][]interface{}{
+		{10, "a"},
+		{11, "b"},
+		{1, "duplicate"},
+	})
+	if err == nil {
+		t.Fatal("Expected duplicate key error")
+	}
+	if count, _ := root.Clone().Table("items").Count(); count != 1 {
+		t.Errorf("Expected earlier batches rolled back, got %d rows", count)
+	}
+
+	// Inside a caller's transaction no transaction is begun and the caller decides
+	err = root.Clone().WithTransaction(func(tx *Builder) error {
+		inserted, err := tx.Clone().Table("items").BatchSize(1).InsertColumns([]string{"name"}, [][]interface{}{{"a"}, {"b"}})
+		if err != nil || inserted != 2 {
+			t.Errorf("Expected 2 rows inserted, got %d (%v)", inserted, err)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	if count, _ := root.Clone().Table("items").Count(); count != 3 {
+		t.Errorf("Expected 3 rows, got %d", count)
+	}
+}
+
+// openTasksDB adds a "tasks" table with column defaults to openNamedDB
+func openTasksDB(t *testing.T) *sql.DB {
+	db := openNamedDB(t)
+	_, err := db.Exec(`CREATE TABLE tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		status TEXT DEFAULT 'open',
+		priority INTEGER DEFAULT 1
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create tasks: %v", err)
+	}
+	return db
+}
+
+func TestInsertBulkHeterogeneousRows(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"title": "a", "status": "done"},
+		{"title": "b", "priority": 5},
+		{"title": "c", "status": "done"},
+	}
+	tasks := func(b *Builder) []map[string]interface{} {
+		result, err := b.Clone().Table("tasks").Select("title", "status", "priority").OrderBy("title", "ASC").ToArray()
+		if err != nil {
+			t.Fatalf("ToArray() failed: %v", err)
+		}
+		return result
+	}
+
+	// Extra keys of later rows are kept; missing ones are NULL by default
+	root := New(openTasksDB(t))
+	if n, err := root.Clone().Table("tasks").InsertBulk(rows); err != nil || n != 3 {
+		t.Fatalf("Expected 3 rows inserted, got %d (%v)", n, err)
+	}
+	got := tasks(root)
+	if got[0]["priority"] != nil || got[1]["priority"] != int64(5) || got[1]["status"] != nil {
+		t.Errorf("Expected NULL for missing columns, got %v", got)
+	}
+
+	// MissingError rejects the rows before anything is written
+	root = New(openTasksDB(t))
+	strict := root.Clone().Table("tasks").MissingColumns(MissingError)
+	if _, err := strict.InsertBulk(rows); err == nil {
+		t.Error("Expected error for missing column")
+	}
+	if count, _ := root.Clone().Table("tasks").Count(); count != 0 {
+		t.Errorf("Expected no rows after error, got %d", count)
+	}
+	if _, err := strict.InsertBulk(rows[:1]); err != nil {
+		t.Errorf("InsertBulk() after a rejected call failed: %v", err)
+	}
+
+	// SQLite has no DEFAULT in VALUES: rows are grouped by their columns
+	var calls []string
+	hook := &recordHook{name: "rec", calls: &calls}
+	root = New(openTasksDB(t), WithHooks(hook))
+	if n, err := root.Clone().Table("tasks").MissingColumns(MissingDefault).InsertBulk(rows); err != nil || n != 3 {
+		t.Fatalf("Expected 3 rows inserted, got %d (%v)", n, err)
+	}
+	got = tasks(root)
+	if got[0]["priority"] != int64(1) || got[1]["status"] != "open" || got[2]["status"] != "done" {
+		t.Errorf("Expected column defaults, got %v", got)
+	}
+
+	var statements []string
+	for _, event := range hook.events {
+		statements = append(statements, event.SQL)
+	}
+	expected := []string{
+		"BEGIN",
+		"INSERT INTO tasks (status, title) VALUES (?, ?), (?, ?)",
+		"INSERT INTO tasks (priority, title) VALUES (?, ?)",
+		"COMMIT",
+	}
+	if !reflect.DeepEqual(statements[:4], expected) {
+		t.Errorf("Expected %v, got %v", expected, statements[:4])
+	}
+}
+
+// defaultsDialect is SQLite claiming DEFAULT support, to check the SQL
+type defaultsDialect struct{ Dialect }
+
+func (defaultsDialect) DefaultValues() bool { return true }
+
+// denyHook cancels every statement
+type denyHook struct{}
+
+func (denyHook) Before(ctx context.Context, event *QueryEvent) (context.Context, error) {
+	return ctx, errors.New("denied")
+}
+
+func (denyHook) After(ctx context.Context, event *QueryEvent) {}
+
+// denyInsertHook cancels inserts but lets transactions begin and end
+type denyInsertHook struct{}
+
+func (denyInsertHook) Before(ctx context.Context, event *QueryEvent) (context.Context, error) {
+	if event.Kind == KindInsert {
+		return ctx, errors.New("denied")
+	}
+	return ctx, nil
+}
+
+func (denyInsertHook) After(ctx context.Context, event *QueryEvent) {}
+
+func TestInsertBulkMissingDefault(t *testing.T) {
+	var calls []string
+	hook := &recordHook{name: "rec", calls: &calls}
+	root := New(openTasksDB(t), WithDialect(defaultsDialect{SQLite}), WithHooks(hook, denyHook{}))
+
+	root.Clone().Table("tasks").MissingColumns(MissingDefault).InsertBulk([]map[string]interface{}{
+		{"title": "a", "status": "done"},
+		{"title": "b", "priority": 5},
+	})
+
+	if len(hook.events) != 1 {
+		t.Fatalf("Expected one statement, got %d", len(hook.events))
+	}
+	event := hook.events[0]
+	if event.SQL != "INSERT INTO tasks (priority, status, title) VALUES (DEFAULT, ?, ?), (?, DEFAULT, ?)" {
+		t.Errorf("Unexpected SQL: %s", event.SQL)
+	}
+	if !reflect.DeepEqual(event.Args, []interface{}{"done", "a", 5, "b"}) {
+		t.Errorf("Unexpected args: %v", event.Args)
+	}
+}
+
+func TestInsertBulk100kRows(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping 100k row insert in short mode")
+	}
+
+	root := New(openNamedDB(t))
+	rows := make([]map[string]interface{}, 100000)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"id": i + 1, "name": fmt.Sprintf("item-%d", i)}
+	}
+
+	inserted, err := root.Clone().Table("items").InsertBulk(rows)
+	if err != nil {
+		t.Fatalf("InsertBulk() failed: %v", err)
+	}
+	if inserted != 100000 {
+		t.Errorf("Expected 100000 rows inserted, got %d", inserted)
+	}
+	if count, _ := root.Clone().Table("items").Count(); count != 100000 {
+		t.Errorf("Expected 100000 rows, got %d", count)
+	}
+}
+
 func TestInsertBulkEmpty(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
-	err := Set(db).Table("users").InsertBulk([]map[string]interface{}{})
-	if err != nil {
+	inserted, err := Set(db).Table("users").InsertBulk([]map[string]interface{}{})
+	if err != nil || inserted != 0 {
 		t.Errorf("InsertBulk() with empty data should not fail: %v", err)
 	}
 }
 
+func TestColumnOrder(t *testing.T) {
+	builder := newBuilder(nil, WithDialect(SQLite))
+	data := map[string]interface{}{"name": "John", "email": "john@example.com", "age": 30}
+
+	for i := 0; i < 10; i++ {
+		query, args := builder.Clone().Table("users").buildInsertQuery(data)
+		if query != "INSERT INTO users (age, email, name) VALUES (?, ?, ?)" {
+			t.Fatalf("Expected sorted columns, got %s", query)
+		}
+		if !reflect.DeepEqual(args, []interface{}{30, "john@example.com", "John"}) {
+			t.Fatalf("Args out of column order: %v", args)
+		}
+	}
+
+	query, _ := builder.Clone().Table("users").ColumnOrder("name", "missing").buildInsertQuery(data)
+	if query != "INSERT INTO users (name, age, email) VALUES (?, ?, ?)" {
+		t.Errorf("Expected caller order first, got %s", query)
+	}
+
+	query, _ = builder.Clone().Table("users").Where("id", "=", 1).buildUpdateQuery(data)
+	if query != "UPDATE users SET age = ?, email = ?, name = ? WHERE id = ?" {
+		t.Errorf("Unexpected update: %s", query)
+	}
+
+	query, _ = builder.Clone().Table("users").buildInsertBulkQuery([]map[string]interface{}{data, data})
+	if query != "INSERT INTO users (age, email, name) VALUES (?, ?, ?), (?, ?, ?)" {
+		t.Errorf("Unexpected bulk insert: %s", query)
+	}
+
+	query, _ = builder.Clone().Table("users").buildUpdateBulkQuery([]map[string]interface{}{
+		{"id": 1, "name": "a", "age": 1},
+	}, "id")
+	if !strings.HasPrefix(query, "UPDATE users SET age = CASE id") {
+		t.Errorf("Unexpected bulk update: %s", query)
+	}
+
+	query, args, err := builder.Clone().Table("users").buildUpsertQuery(data, []string{"email"})
+	if err != nil {
+		t.Fatalf("buildUpsertQuery() failed: %v", err)
+	}
+	if !strings.HasPrefix(query, "INSERT INTO users (age, email, name) VALUES (?, ?, ?)") || args[0] != 30 {
+		t.Errorf("Unexpected upsert: %s %v", query, args)
+	}
+}
+
+func TestInsertColumns(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	inserted, err := DB().Table("users").InsertColumns([]string{"name", "email", "age"}, [][]interface{}{
+		{"User1", "user1@example.com", 20},
+		{"User2", "user2@example.com", nil},
+	})
+	if err != nil {
+		t.Fatalf("InsertColumns() failed: %v", err)
+	}
+	if inserted != 2 {
+		t.Errorf("Expected 2 rows inserted, got %d", inserted)
+	}
+
+	var age sql.NullInt64
+	row, _ := DB().Table("users").Select("age").Where("email", "=", "user2@example.com").First()
+	if err := row.Scan(&age); err != nil || age.Valid {
+		t.Errorf("Expected NULL age, got %v (%v)", age, err)
+	}
+	if count, _ := DB().Table("users").Count(); count != 6 {
+		t.Errorf("Expected 6 users, got %d", count)
+	}
+
+	if _, err := DB().Table("users").InsertColumns([]string{"name", "email"}, [][]interface{}{{"x"}}); err == nil {
+		t.Error("Expected error for short row")
+	}
+	if _, err := DB().Table("users").InsertColumns(nil, [][]interface{}{{}}); err == nil {
+		t.Error("Expected error without columns")
+	}
+	if _, err := DB().Table("users").InsertColumns([]string{"name"}, nil); err != nil {
+		t.Errorf("Empty rows should not fail: %v", err)
+	}
+
+	// A rejected call doesn't fail later writes on the same builder
+	b := DB().Table("users")
+	if _, err := b.InsertColumns([]string{"name", "email"}, [][]interface{}{{"x"}}); err == nil {
+		t.Error("Expected error for short row")
+	}
+	if _, err := b.Insert(map[string]interface{}{"name": "y", "email": "y@example.com"}); err != nil {
+		t.Errorf("Insert() after a rejected InsertColumns failed: %v", err)
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -519,6 +910,156 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestUpdateBulk(t *testing.T) {
+	root := New(openTasksDB(t))
+	root.Clone().Table("tasks").InsertBulk([]map[string]interface{}{
+		{"title": "a", "status": "open"}, {"title": "b", "status": "open"}, {"title": "c", "status": "done"},
+	})
+	tasks := func() []map[string]interface{} {
+		result, err := root.Clone().Table("tasks").Select("title", "status", "priority").OrderBy("id", "ASC").ToArray()
+		if err != nil {
+			t.Fatalf("ToArray() failed: %v", err)
+		}
+		return result
+	}
+
+	// Absent columns are left unchanged; the Where condition excludes row 3
+	updated, err := root.Clone().Table("tasks").Where("status", "=", "open").UpdateBulk([]map[string]interface{}{
+		{"id": 1, "status": "done"},
+		{"id": 2, "priority": 9},
+		{"id": 3, "priority": 9},
+		{"id": 4},
+	}, "id")
+	if err != nil || updated != 2 {
+		t.Fatalf("Expected 2 rows updated, got %d (%v)", updated, err)
+	}
+
+	expected := []map[string]interface{}{
+		{"title": "a", "status": "done", "priority": int64(1)},
+		{"title": "b", "status": "open", "priority": int64(9)},
+		{"title": "c", "status": "done", "priority": int64(1)},
+	}
+	if got := tasks(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	query, args := New(nil).Table("tasks").Where("status", "=", "open").buildUpdateBulkQuery([]map[string]interface{}{
+		{"id": 1, "status": "done"},
+		{"id": 2, "priority": 9},
+	}, "id")
+	want := "UPDATE tasks SET priority = CASE id WHEN ? THEN ? ELSE priority END, " +
+		"status = CASE id WHEN ? THEN ? ELSE status END WHERE (status = ?) AND id IN (?, ?)"
+	if query != want || !reflect.DeepEqual(args, []interface{}{2, 9, 1, "done", "open", 1, 2}) {
+		t.Errorf("Unexpected bulk update:\n%s %v", query, args)
+	}
+}
+
+func TestUpdateBulkCompositeKey(t *testing.T) {
+	db := openNamedDB(t)
+	_, err := db.Exec(`CREATE TABLE stock (warehouse TEXT, sku TEXT, qty INTEGER, PRIMARY KEY (warehouse, sku))`)
+	if err != nil {
+		t.Fatalf("Failed to create stock: %v", err)
+	}
+	root := New(db)
+	root.Clone().Table("stock").InsertColumns([]string{"warehouse", "sku", "qty"}, [][]interface{}{
+		{"east", "a", 1}, {"east", "b", 1}, {"west", "a", 1},
+	})
+
+	updated, err := root.Clone().Table("stock").UpdateBulk([]map[string]interface{}{
+		{"warehouse": "east", "sku": "a", "qty": 5},
+		{"warehouse": "west", "sku": "a", "qty": Raw("qty + ?", 2)},
+	}, "warehouse", "sku")
+	if err != nil || updated != 2 {
+		t.Fatalf("Expected 2 rows updated, got %d (%v)", updated, err)
+	}
+
+	rows, _ := root.Clone().Table("stock").Select("qty").OrderBy("warehouse", "ASC").OrderBy("sku", "ASC").ToArray()
+	var qty []interface{}
+	for _, row := range rows {
+		qty = append(qty, row["qty"])
+	}
+	if !reflect.DeepEqual(qty, []interface{}{int64(5), int64(1), int64(3)}) {
+		t.Errorf("Expected quantities 5, 1, 3, got %v", qty)
+	}
+
+	query, _ := root.Clone().Table("stock").buildUpdateBulkQuery([]map[string]interface{}{
+		{"warehouse": "east", "sku": "a", "qty": 5},
+		{"warehouse": "west", "sku": "a", "qty": 6},
+	}, "warehouse", "sku")
+	want := "UPDATE stock SET qty = CASE WHEN warehouse = ? AND sku = ? THEN ? WHEN warehouse = ? AND sku = ? THEN ? ELSE qty END " +
+		"WHERE ((warehouse = ? AND sku = ?) OR (warehouse = ? AND sku = ?))"
+	if query != want {
+		t.Errorf("Unexpected composite bulk update:\n%s", query)
+	}
+}
+
+func TestUpdateBulkBatches(t *testing.T) {
+	var calls []string
+	hook := &recordHook{name: "rec", calls: &calls}
+	root := New(openTasksDB(t), WithHooks(hook))
+	root.Clone().Table("tasks").InsertBulk([]map[string]interface{}{
+		{"title": "a"}, {"title": "b"}, {"title": "c"},
+	})
+
+	hook.events = nil
+	updated, err := root.Clone().Table("tasks").BatchSize(2).UpdateBulk([]map[string]interface{}{
+		{"id": 1, "priority": 2}, {"id": 2, "priority": 3}, {"id": 3, "priority": 4},
+	}, "id")
+	if err != nil || updated != 3 {
+		t.Fatalf("Expected 3 rows updated, got %d (%v)", updated, err)
+	}
+
+	var kinds []QueryKind
+	for _, event := range hook.events {
+		kinds = append(kinds, event.Kind)
+	}
+	expected := []QueryKind{KindBegin, KindUpdate, KindUpdate, KindCommit}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("Expected %v, got %v", expected, kinds)
+	}
+
+	// Three parameters per row: one row per statement within a limit of 4
+	hook.events = nil
+	small := New(openTasksDB(t), WithDialect(smallParams{SQLite}), WithHooks(hook))
+	small.Clone().Table("tasks").UpdateBulk([]map[string]interface{}{
+		{"id": 1, "priority": 2}, {"id": 2, "priority": 3},
+	}, "id")
+	if len(hook.events) != 4 || len(hook.events[1].Args) != 3 {
+		t.Errorf("Expected two statements of 3 params, got %v", hook.events)
+	}
+
+	// A failing batch rolls back the earlier ones
+	_, err = root.Clone().Table("tasks").BatchSize(1).UpdateBulk([]map[string]interface{}{
+		{"id": 1, "title": "changed"}, {"id": 2, "title": nil},
+	}, "id")
+	if err == nil {
+		t.Fatal("Expected NOT NULL error")
+	}
+	if rows, _ := root.Clone().Table("tasks").Where("id", "=", 1).ToArray(); rows[0]["title"] != "a" {
+		t.Errorf("Expected the first batch rolled back, got %v", rows)
+	}
+}
+
+func TestUpdateBulkErrors(t *testing.T) {
+	root := New(openTasksDB(t))
+
+	b := root.Clone().Table("tasks")
+	if _, err := b.UpdateBulk([]map[string]interface{}{{"id": 1, "title": "a"}}); err == nil {
+		t.Error("Expected error without key columns")
+	}
+	if _, err := b.UpdateBulk([]map[string]interface{}{{"title": "a"}}, "id"); err == nil {
+		t.Error("Expected error for a row without its key")
+	}
+
+	// Rejected calls don't fail later writes on the same builder
+	if _, err := b.Insert(map[string]interface{}{"title": "a"}); err != nil {
+		t.Errorf("Insert() after a rejected UpdateBulk failed: %v", err)
+	}
+	if n, err := root.Clone().Table("tasks").UpdateBulk([]map[string]interface{}{{"id": 1}}, "id"); err != nil || n != 0 {
+		t.Errorf("Expected nothing to update, got %d (%v)", n, err)
+	}
+}
+
 func TestDelete(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -734,3 +1275,269 @@ func TestPrintSQL(t *testing.T) {
 		t.Errorf("Expected SQL:\n%s\nGot:\n%s", expectedSQL, sql)
 	}
 }
+
+func TestWithContextCancelled(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := DB().WithContext(ctx).Table("users").Get()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	_, err = DB().WithContext(ctx).Table("users").Count()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from Count(), got %v", err)
+	}
+
+	_, err = DB().WithContext(ctx).Table("users").Where("id", "=", 1).Delete()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from Delete(), got %v", err)
+	}
+}
+
+func TestBeginTxCancelRollsBack(t *testing.T) {
+	resetSingleton()
+
+	// Cancelled connections are discarded, so use a file database that
+	// survives reconnecting
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "ctx.db"))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, email TEXT, age INTEGER)`)
+	if err != nil {
+		t.Fatalf("Failed to create test table: %v", err)
+	}
+	Set(db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	builder := DB()
+
+	if err := builder.BeginTx(ctx, &sql.TxOptions{}); err != nil {
+		t.Fatalf("BeginTx() failed: %v", err)
+	}
+
+	_, err = builder.Table("users").Insert(map[string]interface{}{
+		"name":  "Ctx User",
+		"email": "ctx@example.com",
+		"age":   31,
+	})
+	if err != nil {
+		t.Fatalf("Insert() failed: %v", err)
+	}
+
+	cancel()
+
+	if err := builder.CommitTransaction(); err == nil {
+		t.Error("Commit should fail after context cancellation")
+	}
+
+	count, err := DB().Table("users").Count()
+	if err != nil {
+		t.Fatalf("Count() failed: %v", err)
+	}
+
+	if count != 0 {
+		t.Errorf("Expected count 0 after cancelled transaction, got %d", count)
+	}
+}
+
+func TestWhereGroup(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	builder := DB().Table("users").
+		Where("age", ">", 20).
+		WhereGroup(func(q *Builder) {
+			q.Where("name", "=", "John Doe").OrWhere("name", "=", "Jane Smith")
+		}).
+		OrWhereGroup(func(q *Builder) {
+			q.Where("age", ">", 30).WhereGroup(func(q *Builder) {
+				q.WhereNull("email").OrWhere("email", "LIKE", "%@example.com")
+			})
+		})
+
+	query, args := builder.buildSelectQuery()
+
+	expectedQuery := "SELECT * FROM users WHERE age > ? AND (name = ? OR name = ?) OR (age > ? AND (email IS NULL OR email LIKE ?))"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	expectedArgs := []interface{}{20, "John Doe", "Jane Smith", 30, "%@example.com"}
+	if !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected args %v, got %v", expectedArgs, args)
+	}
+
+	count, err := builder.Count()
+	if err != nil {
+		t.Fatalf("Count() failed: %v", err)
+	}
+
+	if count != 3 {
+		t.Errorf("Expected count 3, got %d", count)
+	}
+}
+
+func TestWhereGroupEmpty(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	builder := DB().Table("users").WhereGroup(func(q *Builder) {})
+	if len(builder.whereConds) != 0 {
+		t.Errorf("Empty group should be skipped, got %+v", builder.whereConds)
+	}
+}
+
+func TestHavingGroup(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	builder := DB().Table("users").
+		Select("age", "COUNT(*) as total").
+		GroupBy("age").
+		Having("COUNT(*)", ">", 0).
+		HavingGroup(func(q *Builder) {
+			q.Having("age", "<", 26).OrHaving("age", ">", 34)
+		})
+
+	query, args := builder.buildSelectQuery()
+
+	expectedQuery := "SELECT age, COUNT(*) as total FROM users GROUP BY age HAVING COUNT(*) > ? AND (age < ? OR age > ?)"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{0, 26, 34}) {
+		t.Errorf("Args not correct: %v", args)
+	}
+
+	results, err := builder.ToArray()
+	if err != nil {
+		t.Fatalf("ToArray() failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Errorf("Expected 2 groups, got %d", len(results))
+	}
+}
+
+func TestCloneDeepCopiesGroups(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	original := DB().Table("users").WhereGroup(func(q *Builder) {
+		q.Where("age", ">", 25)
+	})
+	clone := original.Clone()
+	clone.whereConds[0].Group[0].Value = 99
+
+	if original.whereConds[0].Group[0].Value != 25 {
+		t.Error("Clone should deep-copy nested condition groups")
+	}
+}
+
+func TestBuilderErrStopsExecution(t *testing.T) {
+	db := setupTestDB(t)
+
+	builder := DB().Table("users").Where("age", "=>", 18).Where("name", "=", "John Doe")
+	if !errors.Is(builder.Err(), ErrInvalidOperator) {
+		t.Fatalf("Expected ErrInvalidOperator, got %v", builder.Err())
+	}
+
+	// Executions fail with the recorded error before reaching the closed database
+	db.Close()
+
+	if _, err := builder.Clone().Get(); err != builder.Err() {
+		t.Errorf("Get: expected recorded error, got %v", err)
+	}
+	if _, err := builder.Clone().ToArray(); err != builder.Err() {
+		t.Errorf("ToArray: expected recorded error, got %v", err)
+	}
+	if _, err := builder.Clone().First(); err != builder.Err() {
+		t.Errorf("First: expected recorded error, got %v", err)
+	}
+	if _, err := builder.Clone().Count(); err != builder.Err() {
+		t.Errorf("Count: expected recorded error, got %v", err)
+	}
+	if _, err := builder.Clone().Update(map[string]interface{}{"age": 1}); err != builder.Err() {
+		t.Errorf("Update: expected recorded error, got %v", err)
+	}
+	if _, err := builder.Clone().Delete(); err != builder.Err() {
+		t.Errorf("Delete: expected recorded error, got %v", err)
+	}
+	if _, err := builder.Clone().InsertBulk(nil); err != builder.Err() {
+		t.Errorf("InsertBulk: expected recorded error, got %v", err)
+	}
+
+	// Only the first error is kept
+	first := builder.Err()
+	builder.OrderBy("name", "sideways")
+	if builder.Err() != first {
+		t.Errorf("Expected first error to be kept, got %v", builder.Err())
+	}
+}
+
+func TestBuilderErrFromSubBuilders(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	bad := DB().Table("users").Select("id").OrderBy("id", "UP")
+
+	tests := map[string]*Builder{
+		"WhereInSub": DB().Table("users").WhereInSub("id", bad),
+		"Where IN":   DB().Table("users").Where("id", "IN", bad),
+		"SelectSub":  DB().Table("users").SelectSub(bad, "x"),
+		"FromSub":    DB().FromSub(bad, "t"),
+		"With":       DB().With("t", bad).Table("t"),
+		"Union":      DB().Table("users").Select("id").Union(bad),
+		"WhereGroup": DB().Table("users").WhereGroup(func(q *Builder) { q.Limit(-1).Where("a", "=", 1) }),
+		"Window":     DB().Table("users").SelectWindow(RowNumber().Over(Window().OrderBy("id", "UP")), "rn"),
+	}
+
+	for name, builder := range tests {
+		if _, err := builder.Get(); err == nil || err != builder.Err() {
+			t.Errorf("%s: expected sub-builder error, got %v", name, err)
+		}
+	}
+}
+
+func TestWhereInEmptyPolicy(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// Default policy refuses to widen the result set
+	_, err := DB().Table("users").WhereIn("id", []interface{}{}).Delete()
+	if !errors.Is(err, ErrEmptyIn) {
+		t.Errorf("Expected ErrEmptyIn, got %v", err)
+	}
+
+	matchNone := newBuilder(db, WithEmptyIn(EmptyInMatchNone))
+
+	builder := matchNone.Clone().Table("users").Where("age", ">", 20).WhereIn("id", []interface{}{})
+	query, _ := builder.buildSelectQuery()
+	if query != "SELECT * FROM users WHERE age > ? AND 1 = 0" {
+		t.Errorf("Unexpected query: %s", query)
+	}
+
+	count, err := builder.Count()
+	if err != nil || count != 0 {
+		t.Errorf("Expected IN () to match no rows, got %d (%v)", count, err)
+	}
+
+	count, err = matchNone.Clone().Table("users").Where("id", "NOT IN", []int{}).Count()
+	if err != nil || count != 4 {
+		t.Errorf("Expected NOT IN () to match every row, got %d (%v)", count, err)
+	}
+
+	count, err = DB().Table("users").Count()
+	if err != nil || count != 4 {
+		t.Errorf("Delete with empty IN must not run, got count %d (%v)", count, err)
+	}
+}
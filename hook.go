@@ -0,0 +1,170 @@
+package gsorm
+
+import (
+	"context"
+	"time"
+)
+
+// QueryKind is the type of statement a QueryEvent describes
+type QueryKind string
+
+// Statement kinds reported to hooks
+const (
+	KindSelect QueryKind = "select"
+	KindInsert QueryKind = "insert"
+	KindUpdate QueryKind = "update"
+	KindDelete QueryKind = "delete"
+	KindUpsert QueryKind = "upsert"
+
+	KindBegin    QueryKind = "begin"
+	KindCommit   QueryKind = "commit"
+	KindRollback QueryKind = "rollback"
+)
+
+// QueryEvent describes a statement sent to the database.
+// SQL is final (placeholders rebound for the dialect).
+type QueryEvent struct {
+	SQL  string
+	Args []interface{}
+	// ArgColumns holds the column each of Args is bound to, as written in
+	// SQL (possibly qualified and quoted), or "" for args bound to no
+	// column, such as Raw args or LIMIT. It is nil when no arg is bound to
+	// a column. A hook that changes Args should update it as well.
+	ArgColumns   []string
+	Kind         QueryKind
+	Table        string
+	Start        time.Time
+	Duration     time.Duration
+	RowsAffected int64 // rows affected by writes; -1 for reads or when unknown
+	Err          error
+}
+
+// Hook observes or intercepts statements around their execution.
+//
+// Before runs in registration order before the statement. It may rewrite
+// event.SQL and event.Args, and return a derived context (e.g. a trace
+// span) that is used to run the statement. Returning an error cancels the
+// statement; the error is returned to the caller.
+//
+// After runs in reverse order once the statement finished or was cancelled,
+// for every hook whose Before succeeded, with the context that hook returned.
+type Hook interface {
+	Before(ctx context.Context, event *QueryEvent) (context.Context, error)
+	After(ctx context.Context, event *QueryEvent)
+}
+
+// WithHooks registers hooks on the root builder; clones and DB() inherit them
+func WithHooks(hooks ...Hook) Option {
+	return func(b *Builder) {
+		b.AddHook(hooks...)
+	}
+}
+
+// AddHook adds hooks to this builder and the builders cloned from it
+func (b *Builder) AddHook(hooks ...Hook) *Builder {
+	// Full slice expression so clones never share appended hooks
+	b.hooks = append(b.hooks[:len(b.hooks):len(b.hooks)], hooks...)
+	return b
+}
+
+// run executes a statement through the hook chain. fn runs the statement
+// and returns the rows it affected (-1 when unknown).
+func (b *Builder) run(ctx context.Context, kind QueryKind, query string, args []interface{}, fn func(ctx context.Context, query string, args []interface{}) (int64, error)) error {
+	args, columns := splitArgs(args)
+	if len(b.hooks) == 0 {
+		_, err := fn(ctx, query, args)
+		return err
+	}
+
+	event := &QueryEvent{
+		SQL:          query,
+		Args:         args,
+		ArgColumns:   columns,
+		Kind:         kind,
+		Table:        b.table,
+		RowsAffected: -1,
+	}
+
+	contexts := make([]context.Context, 0, len(b.hooks))
+	for _, hook := range b.hooks {
+		next, err := hook.Before(ctx, event)
+		if err != nil {
+			event.Err = err
+			break
+		}
+		if next != nil {
+			ctx = next
+		}
+		contexts = append(contexts, ctx)
+	}
+
+	if event.Err == nil {
+		event.Start = time.Now()
+		event.RowsAffected, event.Err = fn(ctx, event.SQL, event.Args)
+		event.Duration = time.Since(event.Start)
+	}
+
+	for i := len(contexts) - 1; i >= 0; i-- {
+		b.hooks[i].After(contexts[i], event)
+	}
+	return event.Err
+}
+
+// columnArg is a bound value tagged with the column it is bound to, so
+// hooks can tell which column each arg belongs to. Values are only tagged
+// on builders with hooks; run strips the tags before the statement is sent.
+type columnArg struct {
+	column string
+	value  interface{}
+}
+
+// bindColumn binds a value written to or compared with column
+func (b *Builder) bindColumn(column string, value interface{}) (string, []interface{}) {
+	marker, args := b.bindValue(value)
+	return marker, b.tagArgs(column, args)
+}
+
+// tagArg returns value tagged with column when the builder has hooks
+func (b *Builder) tagArg(column string, value interface{}) interface{} {
+	if len(b.hooks) == 0 {
+		return value
+	}
+	return columnArg{column: column, value: value}
+}
+
+// tagArgs returns values tagged with column when the builder has hooks
+func (b *Builder) tagArgs(column string, values []interface{}) []interface{} {
+	if len(b.hooks) == 0 {
+		return values
+	}
+	tagged := make([]interface{}, len(values))
+	for i, value := range values {
+		tagged[i] = b.tagArg(column, value)
+	}
+	return tagged
+}
+
+// splitArgs strips the column tags from args. columns is nil when no arg
+// is tagged, and args is then returned as is.
+func splitArgs(args []interface{}) (values []interface{}, columns []string) {
+	for i, arg := range args {
+		tagged, ok := arg.(columnArg)
+		if !ok {
+			if values != nil {
+				values[i] = arg
+			}
+			continue
+		}
+		if values == nil {
+			values = make([]interface{}, len(args))
+			copy(values, args[:i])
+			columns = make([]string, len(args))
+		}
+		values[i] = tagged.value
+		columns[i] = tagged.column
+	}
+	if values == nil {
+		return args, nil
+	}
+	return values, columns
+}
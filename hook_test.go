@@ -0,0 +1,227 @@
+package gsorm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordHook records the events it sees and the order of its calls
+type recordHook struct {
+	name   string
+	calls  *[]string
+	events []QueryEvent
+	err    error
+}
+
+func (h *recordHook) Before(ctx context.Context, event *QueryEvent) (context.Context, error) {
+	*h.calls = append(*h.calls, h.name+".before")
+	return ctx, h.err
+}
+
+func (h *recordHook) After(ctx context.Context, event *QueryEvent) {
+	*h.calls = append(*h.calls, h.name+".after")
+	h.events = append(h.events, *event)
+}
+
+type hookCtxKey struct{}
+
+// ctxHook adds a value to the context, like a tracing span would
+type ctxHook struct {
+	seen []interface{}
+}
+
+func (h *ctxHook) Before(ctx context.Context, event *QueryEvent) (context.Context, error) {
+	return context.WithValue(ctx, hookCtxKey{}, "span"), nil
+}
+
+func (h *ctxHook) After(ctx context.Context, event *QueryEvent) {
+	h.seen = append(h.seen, ctx.Value(hookCtxKey{}))
+}
+
+func TestHookEvents(t *testing.T) {
+	var calls []string
+	hook := &recordHook{name: "rec", calls: &calls}
+	root := New(openNamedDB(t, "a", "b"), WithHooks(hook))
+
+	if _, err := root.Clone().Table("items").Where("id", "=", 1).ToArray(); err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if _, err := root.Clone().Table("items").Insert(map[string]interface{}{"name": "c"}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if _, err := root.Clone().Table("items").Where("id", ">", 1).Update(map[string]interface{}{"name": "x"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, err := root.Clone().Table("items").Where("id", "=", 3).Delete(); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := root.Clone().Table("items").Count(); err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+
+	expected := []struct {
+		kind QueryKind
+		rows int64
+	}{
+		{KindSelect, -1},
+		{KindInsert, 1},
+		{KindUpdate, 2},
+		{KindDelete, 1},
+		{KindSelect, -1},
+	}
+	if len(hook.events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(hook.events))
+	}
+	for i, e := range expected {
+		event := hook.events[i]
+		if event.Kind != e.kind || event.RowsAffected != e.rows {
+			t.Errorf("Event %d: expected %s/%d, got %s/%d", i, e.kind, e.rows, event.Kind, event.RowsAffected)
+		}
+		if event.Table != "items" || event.SQL == "" || event.Err != nil || event.Start.IsZero() {
+			t.Errorf("Event %d incomplete: %+v", i, event)
+		}
+	}
+	if args := hook.events[0].Args; len(args) != 1 || args[0] != 1 {
+		t.Errorf("Expected select args [1], got %v", args)
+	}
+}
+
+func TestHookErrorEvent(t *testing.T) {
+	var calls []string
+	hook := &recordHook{name: "rec", calls: &calls}
+	root := New(openNamedDB(t), WithHooks(hook))
+
+	_, err := root.Clone().Table("missing").ToArray()
+	if err == nil {
+		t.Fatal("Expected error for missing table")
+	}
+	if len(hook.events) != 1 || hook.events[0].Err != err {
+		t.Errorf("Expected the query error in the event, got %+v", hook.events)
+	}
+}
+
+func TestHooksInherited(t *testing.T) {
+	defer resetSingleton()
+	resetSingleton()
+
+	var calls []string
+	hook := &recordHook{name: "rec", calls: &calls}
+	Set(openNamedDB(t, "a"), WithHooks(hook))
+
+	DB().Table("items").Count()
+	DB().Clone().Table("items").Count()
+
+	// Hooks added to a clone stay on that clone
+	var extra []string
+	DB().AddHook(&recordHook{name: "extra", calls: &extra}).Table("items").Count()
+	DB().Table("items").Count()
+
+	if len(hook.events) != 4 {
+		t.Errorf("Expected root hook on every query, got %d events", len(hook.events))
+	}
+	if len(extra) != 2 {
+		t.Errorf("Expected clone hook on one query, got %v", extra)
+	}
+}
+
+func TestHookOrderAndBeforeError(t *testing.T) {
+	var calls []string
+	first := &recordHook{name: "first", calls: &calls}
+	second := &recordHook{name: "second", calls: &calls}
+	root := New(openNamedDB(t), WithHooks(first, second))
+
+	root.Clone().Table("items").Count()
+	expected := "first.before,second.before,second.after,first.after"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	// A failing Before cancels the statement
+	calls = nil
+	denied := errors.New("denied")
+	second.err = denied
+	_, err := root.Clone().Table("items").Insert(map[string]interface{}{"name": "x"})
+	if !errors.Is(err, denied) {
+		t.Fatalf("Expected hook error, got %v", err)
+	}
+	expected = "first.before,second.before,first.after"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if event := first.events[len(first.events)-1]; event.Err != denied || event.Duration != 0 {
+		t.Errorf("Expected cancelled event, got %+v", event)
+	}
+
+	second.err = nil
+	if n, _ := root.Clone().Table("items").Count(); n != 0 {
+		t.Errorf("Cancelled insert should not run, got %d rows", n)
+	}
+}
+
+func TestHookDeniesFirst(t *testing.T) {
+	var calls []string
+	denied := errors.New("denied")
+	hook := &recordHook{name: "deny", calls: &calls, err: denied}
+	root := New(openNamedDB(t, "a"), WithHooks(hook))
+
+	row, err := root.Clone().Table("items").First()
+	if !errors.Is(err, denied) {
+		t.Fatalf("Expected hook error, got %v", err)
+	}
+	if row != nil {
+		t.Errorf("Expected no row when the hook denies the query")
+	}
+}
+
+func TestHookContext(t *testing.T) {
+	hook := &ctxHook{}
+	root := New(openNamedDB(t), WithHooks(hook))
+
+	root.Clone().Table("items").ToArray()
+	if len(hook.seen) != 1 || hook.seen[0] != "span" {
+		t.Errorf("Expected the context returned by Before in After, got %v", hook.seen)
+	}
+}
+
+func TestHookArgColumns(t *testing.T) {
+	var calls []string
+	hook := &recordHook{name: "rec", calls: &calls}
+	root := New(openTasksDB(t), WithHooks(hook))
+
+	root.Clone().Table("tasks").Insert(map[string]interface{}{"title": "a", "priority": Raw("? + ?", 1, 2)})
+	root.Clone().Table("tasks").InsertBulk([]map[string]interface{}{{"title": "b"}, {"title": "c"}})
+	root.Clone().Table("tasks").Where("tasks.id", "IN", []interface{}{1, 2}).Update(map[string]interface{}{"status": "done"})
+	root.Clone().Table("tasks").UpdateBulk([]map[string]interface{}{{"id": 1, "title": "x"}}, "id")
+	root.Clone().Table("tasks").CreateOrUpdate(map[string]interface{}{"id": 1, "title": "y"}, []string{"id"})
+	root.Clone().Table("tasks").Where("priority", "BETWEEN", []interface{}{1, 3}).WhereRaw("status <> ?", "x").Limit(5).ToArray()
+	root.Clone().Table("tasks").Count()
+
+	expected := [][]string{
+		{"priority", "priority", "title"},
+		{"title", "title"},
+		{"status", "tasks.id", "tasks.id"},
+		{"id", "title", "id"},
+		{"id", "title"},
+		{"priority", "priority", "", ""},
+		nil,
+	}
+	if len(hook.events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(hook.events))
+	}
+	for i, want := range expected {
+		if got := hook.events[i].ArgColumns; !reflect.DeepEqual(got, want) {
+			t.Errorf("Event %d (%s): expected columns %q, got %q", i, hook.events[i].SQL, want, got)
+		}
+	}
+
+	// Columns are reported as written in the SQL
+	hook.events = nil
+	New(openTasksDB(t), WithDialect(Postgres), WithIdentifierQuoting(), WithHooks(hook, denyHook{})).
+		Table("tasks").Where("id", "=", 1).Update(map[string]interface{}{"title": "z"})
+	if got := hook.events[0].ArgColumns; !reflect.DeepEqual(got, []string{`"title"`, `"id"`}) {
+		t.Errorf("Expected quoted columns, got %q", got)
+	}
+}
@@ -0,0 +1,145 @@
+package gsorm
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// redacted replaces the value of a redacted column in log output
+const redacted = "[REDACTED]"
+
+// LogOption configures the hook returned by NewLogHook
+type LogOption func(*logHook)
+
+// WithLogLevel sets the level of successful statements (default Debug)
+func WithLogLevel(level slog.Level) LogOption {
+	return func(h *logHook) {
+		h.level = level
+	}
+}
+
+// WithSlowQueryThreshold logs statements that take at least d at Warn
+func WithSlowQueryThreshold(d time.Duration) LogOption {
+	return func(h *logHook) {
+		h.slow = d
+	}
+}
+
+// WithRedactedColumns masks the args bound to the given columns, e.g.
+// "password" or "api_token". Names are matched case-insensitively,
+// ignoring any table qualifier. Args of standalone raw SQL, such as
+// WhereRaw, are bound to no column and not masked.
+func WithRedactedColumns(columns ...string) LogOption {
+	return func(h *logHook) {
+		for _, col := range columns {
+			h.redact[strings.ToLower(col)] = true
+		}
+	}
+}
+
+// WithLogger logs every statement of the builder and its clones via logger
+func WithLogger(logger *slog.Logger, opts ...LogOption) Option {
+	return WithHooks(NewLogHook(logger, opts...))
+}
+
+type logHook struct {
+	logger *slog.Logger
+	level  slog.Level
+	slow   time.Duration
+	redact map[string]bool
+}
+
+// NewLogHook returns a Hook that writes each statement to logger with its
+// kind, table, duration, rows affected and error. Failed statements are
+// logged at Error, slow ones at Warn.
+func NewLogHook(logger *slog.Logger, opts ...LogOption) Hook {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	h := &logHook{
+		logger: logger,
+		level:  slog.LevelDebug,
+		redact: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *logHook) Before(ctx context.Context, event *QueryEvent) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *logHook) After(ctx context.Context, event *QueryEvent) {
+	level := h.level
+	msg := "gsorm query"
+	switch {
+	case event.Err != nil:
+		level = slog.LevelError
+		msg = "gsorm query failed"
+	case h.slow > 0 && event.Duration >= h.slow:
+		level = slog.LevelWarn
+		msg = "gsorm slow query"
+	}
+
+	if !h.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("kind", string(event.Kind)),
+		slog.String("sql", event.SQL),
+		slog.Any("args", h.args(event)),
+		slog.Duration("duration", event.Duration),
+	}
+	if event.Table != "" {
+		attrs = append(attrs, slog.String("table", event.Table))
+	}
+	if event.RowsAffected >= 0 {
+		attrs = append(attrs, slog.Int64("rows", event.RowsAffected))
+	}
+	if event.Err != nil {
+		attrs = append(attrs, slog.String("error", event.Err.Error()))
+	}
+
+	h.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// args returns the event args with the args bound to redacted columns
+// masked. When a hook changed the number of args, the columns no longer
+// line up and every arg is masked.
+func (h *logHook) args(event *QueryEvent) []interface{} {
+	if len(h.redact) == 0 || event.ArgColumns == nil {
+		return event.Args
+	}
+
+	aligned := len(event.ArgColumns) == len(event.Args)
+	var args []interface{}
+	for i := range event.Args {
+		if aligned && !h.redact[columnName(event.ArgColumns[i])] {
+			continue
+		}
+		if args == nil {
+			args = make([]interface{}, len(event.Args))
+			copy(args, event.Args)
+		}
+		args[i] = redacted
+	}
+	if args == nil {
+		return event.Args
+	}
+	return args
+}
+
+// columnName returns the lower case name of a possibly qualified and
+// quoted column, e.g. "password" for `"users"."Password"`
+func columnName(col string) string {
+	if i := strings.LastIndexByte(col, '.'); i >= 0 {
+		col = col[i+1:]
+	}
+	return strings.ToLower(strings.Trim(col, "`\"[]"))
+}
@@ -0,0 +1,143 @@
+package gsorm
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logRecords decodes the JSON lines written by a slog.JSONHandler
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestLogHookStatements(t *testing.T) {
+	var buf bytes.Buffer
+	root := New(openNamedDB(t, "a"), WithLogger(newTestLogger(&buf)))
+
+	err := root.Clone().Table("items").WithTransaction(func(tx *Builder) error {
+		if _, err := tx.Clone().Insert(map[string]interface{}{"name": "b"}); err != nil {
+			return err
+		}
+		_, err := tx.Clone().Where("name", "=", "b").Update(map[string]interface{}{"name": "c"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+	root.Clone().Table("items").Where("id", "=", 1).ToArray()
+	root.Clone().Table("items").Where("name", "=", "c").Delete()
+	root.Clone().Table("items").Insert(map[string]interface{}{"id": 1, "name": "dup"})
+
+	records := logRecords(t, &buf)
+	var kinds []string
+	for _, r := range records {
+		kinds = append(kinds, r["kind"].(string))
+	}
+	expected := []string{"begin", "insert", "update", "commit", "select", "delete", "insert"}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Fatalf("Expected kinds %v, got %v", expected, kinds)
+	}
+
+	update := records[2]
+	if update["level"] != "DEBUG" || update["table"] != "items" || update["rows"] != float64(1) {
+		t.Errorf("Unexpected update record: %v", update)
+	}
+	if _, ok := update["duration"]; !ok {
+		t.Error("Expected duration in record")
+	}
+	if args := update["args"].([]interface{}); len(args) != 2 || args[0] != "c" || args[1] != "b" {
+		t.Errorf("Expected update args [c b], got %v", args)
+	}
+	if _, ok := records[4]["rows"]; ok {
+		t.Error("Reads should not log rows affected")
+	}
+
+	failed := records[6]
+	if failed["level"] != "ERROR" || failed["error"] == nil {
+		t.Errorf("Expected failed insert at ERROR, got %v", failed)
+	}
+}
+
+func TestLogHookLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	// Debug statements are filtered by the handler
+	New(openNamedDB(t), WithLogger(logger)).Table("items").Count()
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output below handler level, got %s", buf.String())
+	}
+
+	New(openNamedDB(t), WithLogger(logger, WithLogLevel(slog.LevelInfo))).Table("items").Count()
+	if records := logRecords(t, &buf); len(records) != 1 || records[0]["level"] != "INFO" {
+		t.Errorf("Expected one INFO record, got %v", records)
+	}
+
+	buf.Reset()
+	New(openNamedDB(t), WithLogger(logger, WithSlowQueryThreshold(time.Nanosecond))).Table("items").Count()
+	records := logRecords(t, &buf)
+	if len(records) != 1 || records[0]["level"] != "WARN" || records[0]["msg"] != "gsorm slow query" {
+		t.Errorf("Expected slow query at WARN, got %v", records)
+	}
+
+	buf.Reset()
+	New(openNamedDB(t), WithLogger(logger, WithSlowQueryThreshold(time.Hour), WithLogLevel(slog.LevelInfo))).Table("items").Count()
+	if records := logRecords(t, &buf); len(records) != 1 || records[0]["level"] != "INFO" {
+		t.Errorf("Expected fast query at INFO, got %v", records)
+	}
+}
+
+func TestLogHookRedaction(t *testing.T) {
+	db := openNamedDB(t)
+	if _, err := db.Exec(`CREATE TABLE accounts (id INTEGER PRIMARY KEY, email TEXT, password TEXT, api_token TEXT)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	var buf bytes.Buffer
+	root := New(db, WithLogger(newTestLogger(&buf), WithRedactedColumns("Password", "api_token")))
+
+	root.Clone().Table("accounts").Insert(map[string]interface{}{
+		"id": 1, "email": "a@example.com", "password": "hunter2", "api_token": "tok",
+	})
+	root.Clone().Table("accounts").Where("email", "=", "a@example.com").Update(map[string]interface{}{"password": "secret"})
+	root.Clone().Table("accounts").Where("accounts.password", "=", "secret").Where("id", "=", 1).ToArray()
+	root.Clone().Table("accounts").Where("email", "=", "a@example.com").Count()
+
+	if out := buf.String(); strings.Contains(out, "hunter2") || strings.Contains(out, "secret") || strings.Contains(out, `"tok"`) {
+		t.Fatalf("Secrets leaked into the log: %s", out)
+	}
+
+	records := logRecords(t, &buf)
+	expected := [][]interface{}{
+		{redacted, "a@example.com", float64(1), redacted},
+		{redacted, "a@example.com"},
+		{redacted, float64(1)},
+		{"a@example.com"},
+	}
+	for i, want := range expected {
+		got := records[i]["args"].([]interface{})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Record %d: expected args %v, got %v", i, want, got)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package gsorm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// InsertStruct inserts a struct using its tagged columns. Read-only fields,
+// zero auto-increment keys and zero omitempty fields are skipped. When v is
+// a pointer the generated id is written back into its auto-increment key,
+// read with RETURNING/OUTPUT where the dialect has it and from
+// LastInsertId otherwise.
+func (b *Builder) InsertStruct(v interface{}) (sql.Result, error) {
+	rv, info, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	data := structData(rv, info, true)
+
+	pk := info.PrimaryKey()
+	if pk == nil || !pk.AutoIncrement || !rv.CanAddr() {
+		return b.Insert(data)
+	}
+
+	field := fieldByIndex(rv, pk.Index)
+	if !field.IsZero() {
+		return b.Insert(data)
+	}
+
+	if clause, _ := b.dialect.Returning(KindInsert, []string{b.ident(pk.Column)}); clause != "" {
+		// pq, pgx and go-mssqldb don't implement LastInsertId
+		if _, err := b.Returning(pk.Column).Into(rv.Addr().Interface()).Insert(data); err != nil {
+			return nil, err
+		}
+		return insertedResult(intValue(field)), nil
+	}
+
+	result, err := b.Insert(data)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return result, err
+	}
+	return result, setInt(field, id)
+}
+
+// insertedResult is the sql.Result of an InsertStruct that read its
+// generated id with RETURNING
+type insertedResult int64
+
+func (r insertedResult) LastInsertId() (int64, error) {
+	return int64(r), nil
+}
+
+func (r insertedResult) RowsAffected() (int64, error) {
+	return 1, nil
+}
+
+// InsertStructs bulk inserts a slice of structs or struct pointers. Zero
+// omitempty fields are left to the column default, as with InsertStruct.
+// Generated ids are not written back, since a bulk insert only reports the
+// last one; use InsertStruct or Returning when the ids are needed.
+func (b *Builder) InsertStructs(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("gsorm: InsertStructs expects a slice, got %T", v)
+	}
+
+	data := make([]map[string]interface{}, rv.Len())
+	for i := range data {
+		item, info, err := structValue(rv.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+		data[i] = structData(item, info, true)
+	}
+
+	policy := b.missing
+	b.missing = MissingDefault
+	_, err := b.InsertBulk(data)
+	b.missing = policy
+	return err
+}
+
+// UpdateStruct updates the writable columns of a struct. Without WHERE
+// conditions the row is matched by its primary key.
+func (b *Builder) UpdateStruct(v interface{}) (sql.Result, error) {
+	rv, info, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	data := structData(rv, info, false)
+
+	if len(b.whereConds) == 0 {
+		pk := info.PrimaryKey()
+		if pk == nil {
+			return nil, fmt.Errorf("gsorm: UpdateStruct needs WHERE conditions or a primary key on %s", info.Type)
+		}
+
+		key := fieldByIndex(rv, pk.Index)
+		if key.IsZero() {
+			return nil, fmt.Errorf("gsorm: UpdateStruct primary key %s is empty", pk.Column)
+		}
+		b.Where(pk.Column, "=", key.Interface())
+	}
+
+	return b.Update(data)
+}
+
+// CreateOrUpdateStruct performs UPSERT operation from a struct
+func (b *Builder) CreateOrUpdateStruct(v interface{}, conflictColumns []string) (sql.Result, error) {
+	rv, info, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return b.CreateOrUpdate(structData(rv, info, true), conflictColumns)
+}
+
+// structValue dereferences v and returns its struct metadata
+func structValue(v interface{}) (reflect.Value, *structInfo, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}, nil, fmt.Errorf("gsorm: nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, nil, fmt.Errorf("gsorm: expected a struct, got %T", v)
+	}
+	return rv, getStructInfo(rv.Type()), nil
+}
+
+// structData collects the writable column values of rv. Primary keys are
+// kept on insert (unless generated) and dropped on update.
+func structData(rv reflect.Value, info *structInfo, insert bool) map[string]interface{} {
+	data := make(map[string]interface{}, len(info.Fields))
+
+	for _, fi := range info.Fields {
+		if fi.ReadOnly || (fi.PrimaryKey && !insert) {
+			continue
+		}
+
+		field, ok := fieldValue(rv, fi.Index)
+		if !ok {
+			// Field lives in a nil embedded pointer
+			continue
+		}
+
+		if field.IsZero() && (fi.OmitEmpty || (fi.AutoIncrement && insert)) {
+			continue
+		}
+		data[fi.Column] = field.Interface()
+	}
+
+	return data
+}
+
+// fieldValue reads the field at index without allocating nil embedded pointers
+func fieldValue(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// intValue reads an integer field set by setInt or a scan
+func intValue(field reflect.Value) int64 {
+	switch field.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(field.Uint())
+	}
+	return field.Int()
+}
+
+// setInt assigns a generated id to an integer field
+func setInt(field reflect.Value, id int64) error {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(uint64(id))
+	default:
+		return fmt.Errorf("gsorm: cannot write generated id into %s field", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package gsorm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type modelUser struct {
+	ID        int64     `gsorm:"id,pk,autoincrement"`
+	Name      string    `gsorm:"name"`
+	Email     string    `gsorm:"email"`
+	Age       int       `gsorm:"age,omitempty"`
+	CreatedAt time.Time `gsorm:"created_at,readonly"`
+}
+
+func (modelUser) TableName() string {
+	return "users"
+}
+
+func TestStructTagOptions(t *testing.T) {
+	info := getStructInfo(reflect.TypeOf(modelUser{}))
+
+	pk := info.PrimaryKey()
+	if pk == nil || pk.Column != "id" || !pk.AutoIncrement {
+		t.Fatalf("Expected auto-increment primary key id, got %+v", pk)
+	}
+
+	if fi := info.lookup("age"); fi == nil || !fi.OmitEmpty {
+		t.Errorf("Expected age to be omitempty: %+v", fi)
+	}
+
+	if fi := info.lookup("created_at"); fi == nil || !fi.ReadOnly {
+		t.Errorf("Expected created_at to be readonly: %+v", fi)
+	}
+
+	implicit := getStructInfo(reflect.TypeOf(queryUser{})).PrimaryKey()
+	if implicit == nil || implicit.Column != "id" || !implicit.AutoIncrement {
+		t.Errorf("Expected implicit integer id primary key, got %+v", implicit)
+	}
+}
+
+func TestStructData(t *testing.T) {
+	user := modelUser{Name: "Test", Email: "test@example.com", CreatedAt: time.Now()}
+	data := structData(reflect.ValueOf(user), getStructInfo(reflect.TypeOf(user)), true)
+
+	expected := map[string]interface{}{"name": "Test", "email": "test@example.com"}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("Expected %v, got %v", expected, data)
+	}
+}
+
+func TestInsertStruct(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	user := modelUser{Name: "Struct User", Email: "struct@example.com", Age: 41}
+	result, err := DB().Table("users").InsertStruct(&user)
+	if err != nil {
+		t.Fatalf("InsertStruct() failed: %v", err)
+	}
+
+	if user.ID != 5 {
+		t.Errorf("Expected generated id 5 written back, got %d", user.ID)
+	}
+	if id, err := result.LastInsertId(); err != nil || id != 5 {
+		t.Errorf("Expected LastInsertId 5, got %d (%v)", id, err)
+	}
+
+	var stored modelUser
+	if err := DB().Table("users").Where("id", "=", user.ID).ScanOne(&stored); err != nil {
+		t.Fatalf("ScanOne() failed: %v", err)
+	}
+
+	if stored.Email != user.Email || stored.Age != 41 || stored.CreatedAt.IsZero() {
+		t.Errorf("Stored user not correct: %+v", stored)
+	}
+}
+
+func TestInsertStructReturningSQL(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{Postgres, "INSERT INTO users (email, name) VALUES ($1, $2) RETURNING id"},
+		{SQLServer, "INSERT INTO users (email, name) OUTPUT INSERTED.id VALUES (@p1, @p2)"},
+		{MySQL, "INSERT INTO users (email, name) VALUES (?, ?)"},
+	}
+
+	for _, tt := range tests {
+		var calls []string
+		hook := &recordHook{name: "rec", calls: &calls}
+		user := modelUser{Name: "a", Email: "a@example.com"}
+		New(openNamedDB(t), WithDialect(tt.dialect), WithHooks(hook, denyHook{})).Table("users").InsertStruct(&user)
+
+		if len(hook.events) != 1 || hook.events[0].SQL != tt.want {
+			t.Errorf("[%s] expected %s, got %v", tt.dialect.Name(), tt.want, hook.events)
+		}
+	}
+}
+
+func TestInsertStructs(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	users := []*modelUser{
+		{Name: "Bulk One", Email: "bulk1@example.com", Age: 20},
+		{Name: "Bulk Two", Email: "bulk2@example.com", Age: 21},
+	}
+	if err := DB().Table("users").InsertStructs(users); err != nil {
+		t.Fatalf("InsertStructs() failed: %v", err)
+	}
+
+	count, err := DB().Table("users").Count()
+	if err != nil {
+		t.Fatalf("Count() failed: %v", err)
+	}
+
+	if count != 6 {
+		t.Errorf("Expected count 6, got %d", count)
+	}
+
+	if err := DB().Table("users").InsertStructs(users[0]); err == nil {
+		t.Error("InsertStructs() should reject non-slices")
+	}
+}
+
+type modelTask struct {
+	ID     int64  `gsorm:"id,pk,autoincrement"`
+	Title  string `gsorm:"title"`
+	Status string `gsorm:"status,omitempty"`
+}
+
+func TestInsertStructsOmitEmptyDefault(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if _, err := db.Exec(`CREATE TABLE tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'todo'
+	)`); err != nil {
+		t.Fatalf("Failed to create tasks table: %v", err)
+	}
+
+	tasks := []modelTask{{Title: "a"}, {Title: "b", Status: "done"}}
+	if err := DB().Table("tasks").InsertStructs(tasks); err != nil {
+		t.Fatalf("InsertStructs() failed: %v", err)
+	}
+
+	var stored []modelTask
+	if err := DB().Table("tasks").OrderBy("id", "ASC").ScanAll(&stored); err != nil {
+		t.Fatalf("ScanAll() failed: %v", err)
+	}
+
+	if len(stored) != 2 || stored[0].Status != "todo" || stored[1].Status != "done" {
+		t.Errorf("Expected default status for the omitted field, got %+v", stored)
+	}
+}
+
+func TestUpdateStruct(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	user := modelUser{ID: 1, Name: "John Updated", Email: "john@example.com", Age: 26}
+	result, err := DB().Table("users").UpdateStruct(&user)
+	if err != nil {
+		t.Fatalf("UpdateStruct() failed: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		t.Fatalf("RowsAffected() failed: %v", err)
+	}
+
+	if rowsAffected != 1 {
+		t.Errorf("Expected 1 row affected, got %d", rowsAffected)
+	}
+
+	var stored modelUser
+	if err := DB().Table("users").Where("id", "=", 1).ScanOne(&stored); err != nil {
+		t.Fatalf("ScanOne() failed: %v", err)
+	}
+
+	if stored.Name != "John Updated" || stored.Age != 26 {
+		t.Errorf("User not updated: %+v", stored)
+	}
+
+	if _, err := DB().Table("users").UpdateStruct(modelUser{Name: "No Key"}); err == nil {
+		t.Error("UpdateStruct() without key or WHERE should fail")
+	}
+}
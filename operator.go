@@ -0,0 +1,136 @@
+package gsorm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// condKind tells buildWhereClause how a condition binds its value
+type condKind int
+
+const (
+	condCompare condKind = iota // column op ?
+	condList                    // column IN (?, ?, ...)
+	condBetween                 // column BETWEEN ? AND ?
+	condNull                    // column IS [NOT] NULL
+	condSub                     // [column] op (SELECT ...)
+	condColumn                  // column op column
+	condRaw                     // raw expression
+)
+
+// operators is the allowlist of Where/Having operators and the kind of
+// condition each one builds
+var operators = map[string]condKind{
+	"=":        condCompare,
+	"!=":       condCompare,
+	"<>":       condCompare,
+	"<":        condCompare,
+	"<=":       condCompare,
+	">":        condCompare,
+	">=":       condCompare,
+	"LIKE":     condCompare,
+	"NOT LIKE": condCompare,
+	"ILIKE":    condCompare,
+	"IN":       condList,
+	"NOT IN":   condList,
+	"BETWEEN":  condBetween,
+	"IS":       condNull,
+	"IS NOT":   condNull,
+}
+
+// ErrInvalidOperator is returned when a condition uses an operator outside
+// the allowlist, or a value the operator can't bind
+var ErrInvalidOperator = errors.New("gsorm: invalid operator")
+
+// ErrEmptyIn is returned for an IN condition with an empty list under the
+// default EmptyInError policy
+var ErrEmptyIn = errors.New("gsorm: empty IN list")
+
+// condition validates operator and value and builds the matching condition.
+// It records an error and returns false when they don't fit together.
+func (b *Builder) condition(column, operator string, value interface{}, logic string) (WhereCondition, bool) {
+	op := strings.ToUpper(strings.Join(strings.Fields(operator), " "))
+	kind, ok := operators[op]
+	if !ok {
+		b.setErr(fmt.Errorf("%w: %q", ErrInvalidOperator, operator))
+		return WhereCondition{}, false
+	}
+
+	cond := WhereCondition{
+		Column:   b.ident(column),
+		Operator: op,
+		Value:    value,
+		Logic:    logic,
+		kind:     kind,
+	}
+
+	if sub, isSub := value.(*Builder); isSub && (kind == condCompare || kind == condList) {
+		b.inherit(sub)
+		cond.Value = sub.Clone()
+		cond.kind = condSub
+		return cond, true
+	}
+
+	switch kind {
+	case condCompare:
+		if op == "ILIKE" && b.dialect.Name() != "postgres" {
+			// Case-insensitive LIKE for databases without ILIKE
+			cond.Column = "LOWER(" + cond.Column + ")"
+			cond.Operator = "LIKE"
+			cond.Value = Expr{SQL: "LOWER(?)", Args: []interface{}{value}}
+		}
+
+	case condList:
+		values, isList := listValues(value)
+		if !isList {
+			b.setErr(fmt.Errorf("%w: %s needs a slice or subquery, got %T", ErrInvalidOperator, op, value))
+			return WhereCondition{}, false
+		}
+		if len(values) == 0 && b.emptyIn == EmptyInError {
+			b.setErr(fmt.Errorf("%w for %s", ErrEmptyIn, column))
+			return WhereCondition{}, false
+		}
+		cond.Value = values
+
+	case condBetween:
+		values, isList := listValues(value)
+		if !isList || len(values) != 2 {
+			b.setErr(fmt.Errorf("%w: BETWEEN needs a slice of two bounds, got %v", ErrInvalidOperator, value))
+			return WhereCondition{}, false
+		}
+		cond.Value = values
+
+	case condNull:
+		if value != nil {
+			b.setErr(fmt.Errorf("%w: %s only compares with nil, got %T", ErrInvalidOperator, op, value))
+			return WhereCondition{}, false
+		}
+		cond.Operator = op + " NULL"
+	}
+
+	return cond, true
+}
+
+// listValues flattens any slice (except []byte) into []interface{}
+func listValues(value interface{}) ([]interface{}, bool) {
+	if values, ok := value.([]interface{}); ok {
+		return values, true
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		// []byte is a single value
+		return nil, false
+	}
+
+	values := make([]interface{}, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values, true
+}
@@ -0,0 +1,141 @@
+package gsorm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWhereOperators(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	tests := []struct {
+		operator string
+		value    interface{}
+		clause   string
+		args     []interface{}
+	}{
+		{"=", 30, "age = ?", []interface{}{30}},
+		{"!=", 30, "age != ?", []interface{}{30}},
+		{"<>", 30, "age <> ?", []interface{}{30}},
+		{"<", 30, "age < ?", []interface{}{30}},
+		{"<=", 30, "age <= ?", []interface{}{30}},
+		{">", 30, "age > ?", []interface{}{30}},
+		{">=", 30, "age >= ?", []interface{}{30}},
+		{"like", "J%", "age LIKE ?", []interface{}{"J%"}},
+		{"not  like", "J%", "age NOT LIKE ?", []interface{}{"J%"}},
+		{"IN", []int{25, 30}, "age IN (?,?)", []interface{}{25, 30}},
+		{"NOT IN", []interface{}{25}, "age NOT IN (?)", []interface{}{25}},
+		{"BETWEEN", []int{20, 30}, "age BETWEEN ? AND ?", []interface{}{20, 30}},
+		{"IS", nil, "age IS NULL", []interface{}{}},
+		{"is not", nil, "age IS NOT NULL", []interface{}{}},
+	}
+
+	for _, tt := range tests {
+		builder := DB().Table("users").Where("age", tt.operator, tt.value)
+		if builder.err != nil {
+			t.Errorf("%s: unexpected error %v", tt.operator, builder.err)
+			continue
+		}
+
+		clause, args := builder.buildWhereClause(builder.whereConds)
+		if clause != tt.clause {
+			t.Errorf("%s: expected %q, got %q", tt.operator, tt.clause, clause)
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%s: expected args %v, got %v", tt.operator, tt.args, args)
+		}
+
+		if _, err := builder.Count(); err != nil {
+			t.Errorf("%s: query failed: %v", tt.operator, err)
+		}
+	}
+}
+
+func TestWhereOperatorsFilterRows(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	count, err := DB().Table("users").Where("age", "BETWEEN", []int{26, 32}).Count()
+	if err != nil || count != 2 {
+		t.Errorf("BETWEEN: expected 2 rows, got %d (%v)", count, err)
+	}
+
+	// NOT LIKE contains "IN" but must still bind a single value
+	count, err = DB().Table("users").Where("name", "NOT LIKE", "%Smith").Count()
+	if err != nil || count != 3 {
+		t.Errorf("NOT LIKE: expected 3 rows, got %d (%v)", count, err)
+	}
+
+	count, err = DB().Table("users").Where("name", "ILIKE", "jane%").Count()
+	if err != nil || count != 1 {
+		t.Errorf("ILIKE: expected 1 row, got %d (%v)", count, err)
+	}
+
+	count, err = DB().Table("users").Where("id", "IN", DB().Table("users").Select("id").Where("age", ">", 30)).Count()
+	if err != nil || count != 1 {
+		t.Errorf("IN subquery: expected 1 row, got %d (%v)", count, err)
+	}
+}
+
+func TestWhereILikeDialects(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		clause  string
+	}{
+		{Postgres, "name ILIKE ?"},
+		{MySQL, "LOWER(name) LIKE LOWER(?)"},
+		{SQLite, "LOWER(name) LIKE LOWER(?)"},
+	}
+
+	for _, tt := range tests {
+		builder := newBuilder(nil, WithDialect(tt.dialect)).Table("users").Where("name", "ilike", "j%")
+		clause, args := builder.buildWhereClause(builder.whereConds)
+		if clause != tt.clause || !reflect.DeepEqual(args, []interface{}{"j%"}) {
+			t.Errorf("[%s] expected %q, got %q %v", tt.dialect.Name(), tt.clause, clause, args)
+		}
+	}
+}
+
+func TestWhereInvalidOperators(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	tests := []struct {
+		name     string
+		operator string
+		value    interface{}
+	}{
+		{"injected text", "= 1 OR 1 = 1 --", 1},
+		{"statement", "; DROP TABLE users;", 1},
+		{"unknown", "REGEXP", "x"},
+		{"empty", "", 1},
+		{"IN without slice", "IN", 1},
+		{"BETWEEN one bound", "BETWEEN", []int{1}},
+		{"IS with value", "IS", 1},
+	}
+
+	for _, tt := range tests {
+		builder := DB().Table("users").Where("age", tt.operator, tt.value)
+		if len(builder.whereConds) != 0 {
+			t.Errorf("%s: condition should not be added", tt.name)
+		}
+
+		if _, err := builder.Delete(); !errors.Is(err, ErrInvalidOperator) {
+			t.Errorf("%s: expected ErrInvalidOperator, got %v", tt.name, err)
+		}
+	}
+
+	if _, err := DB().Table("users").Having("age", "LIKE IN", 1).Get(); !errors.Is(err, ErrInvalidOperator) {
+		t.Errorf("Having: expected ErrInvalidOperator, got %v", err)
+	}
+	if _, err := DB().Table("users").WhereColumn("id", "IN", "age").Get(); !errors.Is(err, ErrInvalidOperator) {
+		t.Errorf("WhereColumn: expected ErrInvalidOperator, got %v", err)
+	}
+
+	count, err := DB().Table("users").Count()
+	if err != nil || count != 4 {
+		t.Errorf("No rows should be deleted, got count %d (%v)", count, err)
+	}
+}
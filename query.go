@@ -0,0 +1,238 @@
+package gsorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// TypedQuery is a Builder bound to the model type T.
+// It exposes the same fluent methods and returns T values instead of maps.
+type TypedQuery[T any] struct {
+	b     *Builder
+	info  *structInfo
+	table string
+}
+
+// Query starts a typed query for T on the default connection.
+// The table defaults to T's TableName() or its pluralised snake_case name.
+func Query[T any](table ...string) *TypedQuery[T] {
+	return From[T](DB(), table...)
+}
+
+// From starts a typed query for T on an existing builder (e.g. a transaction).
+// The query works on a clone, so b itself is left unchanged.
+func From[T any](b *Builder, table ...string) *TypedQuery[T] {
+	var zero T
+	t := reflect.TypeOf(zero)
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("gsorm: Query type must be a struct, got %T", zero))
+	}
+
+	info := getStructInfo(t)
+	name := info.Table
+	if len(table) > 0 && table[0] != "" {
+		name = table[0]
+	}
+
+	// Qualify columns so joined tables don't make them ambiguous
+	cols := make([]string, len(info.Fields))
+	for i, fi := range info.Fields {
+		cols[i] = name + "." + fi.Column
+	}
+
+	return &TypedQuery[T]{
+		b:     b.Clone().Table(name).Select(cols...),
+		info:  info,
+		table: name,
+	}
+}
+
+// Builder returns the underlying builder for operations not exposed on TypedQuery
+func (q *TypedQuery[T]) Builder() *Builder {
+	return q.b
+}
+
+// Err returns the first error recorded while building the query
+func (q *TypedQuery[T]) Err() error {
+	return q.b.Err()
+}
+
+// WithContext sets the context used by the query
+func (q *TypedQuery[T]) WithContext(ctx context.Context) *TypedQuery[T] {
+	q.b.WithContext(ctx)
+	return q
+}
+
+// OnPrimary reads from the primary instead of a replica
+func (q *TypedQuery[T]) OnPrimary() *TypedQuery[T] {
+	q.b.OnPrimary()
+	return q
+}
+
+// Select overrides the columns selected from the model
+func (q *TypedQuery[T]) Select(cols ...string) *TypedQuery[T] {
+	q.b.Select(cols...)
+	return q
+}
+
+// Where adds WHERE condition with prepared statements
+func (q *TypedQuery[T]) Where(column string, operator string, value interface{}) *TypedQuery[T] {
+	q.b.Where(column, operator, value)
+	return q
+}
+
+// OrWhere adds WHERE condition with OR logic
+func (q *TypedQuery[T]) OrWhere(column string, operator string, value interface{}) *TypedQuery[T] {
+	q.b.OrWhere(column, operator, value)
+	return q
+}
+
+// WhereGroup adds a parenthesised group of conditions built by fn
+func (q *TypedQuery[T]) WhereGroup(fn func(*Builder)) *TypedQuery[T] {
+	q.b.WhereGroup(fn)
+	return q
+}
+
+// OrWhereGroup adds a parenthesised group of conditions with OR logic
+func (q *TypedQuery[T]) OrWhereGroup(fn func(*Builder)) *TypedQuery[T] {
+	q.b.OrWhereGroup(fn)
+	return q
+}
+
+// WhereRaw adds a raw WHERE condition with bound args
+func (q *TypedQuery[T]) WhereRaw(sql string, args ...interface{}) *TypedQuery[T] {
+	q.b.WhereRaw(sql, args...)
+	return q
+}
+
+// WhereIn adds safe WHERE IN condition
+func (q *TypedQuery[T]) WhereIn(column string, values []interface{}) *TypedQuery[T] {
+	q.b.WhereIn(column, values)
+	return q
+}
+
+// WhereNull adds WHERE column IS NULL condition
+func (q *TypedQuery[T]) WhereNull(column string) *TypedQuery[T] {
+	q.b.WhereNull(column)
+	return q
+}
+
+// WhereNotNull adds WHERE column IS NOT NULL condition
+func (q *TypedQuery[T]) WhereNotNull(column string) *TypedQuery[T] {
+	q.b.WhereNotNull(column)
+	return q
+}
+
+// LeftJoin adds LEFT JOIN
+func (q *TypedQuery[T]) LeftJoin(table, condition string) *TypedQuery[T] {
+	q.b.LeftJoin(table, condition)
+	return q
+}
+
+// RightJoin adds RIGHT JOIN
+func (q *TypedQuery[T]) RightJoin(table, condition string) *TypedQuery[T] {
+	q.b.RightJoin(table, condition)
+	return q
+}
+
+// InnerJoin adds INNER JOIN
+func (q *TypedQuery[T]) InnerJoin(table, condition string) *TypedQuery[T] {
+	q.b.InnerJoin(table, condition)
+	return q
+}
+
+// OrderBy adds ORDER BY clause
+func (q *TypedQuery[T]) OrderBy(column, direction string) *TypedQuery[T] {
+	q.b.OrderBy(column, direction)
+	return q
+}
+
+// OrderByRaw adds an ORDER BY expression with bound args
+func (q *TypedQuery[T]) OrderByRaw(sql string, args ...interface{}) *TypedQuery[T] {
+	q.b.OrderByRaw(sql, args...)
+	return q
+}
+
+// GroupBy adds GROUP BY clause
+func (q *TypedQuery[T]) GroupBy(columns ...string) *TypedQuery[T] {
+	q.b.GroupBy(columns...)
+	return q
+}
+
+// Having adds HAVING condition
+func (q *TypedQuery[T]) Having(column string, operator string, value interface{}) *TypedQuery[T] {
+	q.b.Having(column, operator, value)
+	return q
+}
+
+// Limit sets the LIMIT clause
+func (q *TypedQuery[T]) Limit(limit int) *TypedQuery[T] {
+	q.b.Limit(limit)
+	return q
+}
+
+// Offset sets the OFFSET clause for pagination
+func (q *TypedQuery[T]) Offset(offset int) *TypedQuery[T] {
+	q.b.Offset(offset)
+	return q
+}
+
+// Paginate sets up pagination
+func (q *TypedQuery[T]) Paginate(page, perPage int) *TypedQuery[T] {
+	q.b.Paginate(page, perPage)
+	return q
+}
+
+// Clone creates a copy of the query for reuse
+func (q *TypedQuery[T]) Clone() *TypedQuery[T] {
+	return &TypedQuery[T]{b: q.b.Clone(), info: q.info, table: q.table}
+}
+
+// All returns every matching row
+func (q *TypedQuery[T]) All() ([]T, error) {
+	results := make([]T, 0)
+	if err := q.b.ScanAll(&results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// One returns the first matching row, or sql.ErrNoRows
+func (q *TypedQuery[T]) One() (T, error) {
+	var result T
+	err := q.b.Clone().ScanOne(&result)
+	return result, err
+}
+
+// Find looks up a row by primary key; the bool reports whether it exists.
+// The query itself is left unchanged, so it can be reused.
+func (q *TypedQuery[T]) Find(id interface{}) (T, bool, error) {
+	var result T
+
+	pk := q.info.PrimaryKey()
+	if pk == nil {
+		return result, false, fmt.Errorf("gsorm: %s has no primary key field", q.info.Type)
+	}
+
+	err := q.b.Clone().Where(q.table+"."+pk.Column, "=", id).ScanOne(&result)
+	if errors.Is(err, sql.ErrNoRows) {
+		return result, false, nil
+	}
+	if err != nil {
+		return result, false, err
+	}
+	return result, true, nil
+}
+
+// Count counts the matching rows
+func (q *TypedQuery[T]) Count() (int64, error) {
+	return q.b.Count()
+}
+
+// PrintSQL displays the SQL to be executed
+func (q *TypedQuery[T]) PrintSQL() string {
+	return q.b.PrintSQL()
+}
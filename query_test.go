@@ -0,0 +1,164 @@
+package gsorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+type queryUser struct {
+	ID    int64
+	Name  string
+	Email string
+	Age   int
+}
+
+func (queryUser) TableName() string {
+	return "users"
+}
+
+type OrderItem struct {
+	ID int64
+}
+
+type Category struct {
+	ID int64
+}
+
+func TestQueryTableName(t *testing.T) {
+	tests := map[string]string{
+		"users":       getStructInfo(reflect.TypeOf(queryUser{})).Table,
+		"order_items": getStructInfo(reflect.TypeOf(OrderItem{})).Table,
+		"categories":  getStructInfo(reflect.TypeOf(Category{})).Table,
+	}
+
+	for expected, got := range tests {
+		if got != expected {
+			t.Errorf("Expected table '%s', got '%s'", expected, got)
+		}
+	}
+}
+
+func TestQuerySQL(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	sql := Query[queryUser]().Where("age", ">", 25).OrderBy("name", "ASC").PrintSQL()
+
+	expected := "SELECT users.id, users.name, users.email, users.age FROM users WHERE age > 25 ORDER BY name ASC"
+	if sql != expected {
+		t.Errorf("Expected SQL:\n%s\nGot:\n%s", expected, sql)
+	}
+}
+
+func TestQueryAll(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	users, err := Query[queryUser]().Where("age", ">=", 30).OrderBy("age", "ASC").All()
+	if err != nil {
+		t.Fatalf("All() failed: %v", err)
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("Expected 2 users, got %d", len(users))
+	}
+
+	if users[0].Name != "Jane Smith" || users[1].Age != 35 {
+		t.Errorf("Users not scanned correctly: %+v", users)
+	}
+
+	none, err := Query[queryUser]().Where("age", ">", 100).All()
+	if err != nil {
+		t.Fatalf("All() failed: %v", err)
+	}
+
+	if none == nil || len(none) != 0 {
+		t.Errorf("Expected empty non-nil slice, got %#v", none)
+	}
+}
+
+func TestQueryOne(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	user, err := Query[queryUser]().Where("email", "=", "jane@example.com").One()
+	if err != nil {
+		t.Fatalf("One() failed: %v", err)
+	}
+
+	if user.Name != "Jane Smith" {
+		t.Errorf("Expected Jane Smith, got %s", user.Name)
+	}
+
+	// One doesn't limit a reused query
+	q := Query[queryUser]()
+	if _, err := q.One(); err != nil {
+		t.Fatalf("One() failed: %v", err)
+	}
+	users, err := q.All()
+	if err != nil || len(users) != 4 {
+		t.Errorf("Expected 4 users after One(), got %d (%v)", len(users), err)
+	}
+}
+
+func TestQueryFind(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	user, found, err := Query[queryUser]().Find(2)
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	if !found || user.Email != "jane@example.com" {
+		t.Errorf("Expected to find Jane, got found=%v %+v", found, user)
+	}
+
+	_, found, err = Query[queryUser]().Find(999)
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+
+	if found {
+		t.Error("Find() should report missing rows")
+	}
+
+	// Find doesn't add its condition to a reused query
+	q := Query[queryUser]()
+	for _, id := range []int64{1, 2} {
+		user, found, err := q.Find(id)
+		if err != nil || !found || user.ID != id {
+			t.Errorf("Expected to find user %d on a reused query, got found=%v %+v (%v)", id, found, user, err)
+		}
+	}
+}
+
+func TestQueryFromTransaction(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	err := DB().WithTransaction(func(tx *Builder) error {
+		count, err := From[queryUser](tx).Where("age", "<", 30).Count()
+		if err != nil {
+			return err
+		}
+
+		if count != 2 {
+			t.Errorf("Expected count 2, got %d", count)
+		}
+
+		// The transaction builder doesn't keep the previous query's conditions
+		count, err = From[queryUser](tx).Count()
+		if err != nil {
+			return err
+		}
+
+		if count != 4 {
+			t.Errorf("Expected count 4, got %d", count)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package gsorm
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// DefaultConnection is the registry name of the connection used by Set and DB
+const DefaultConnection = "default"
+
+// registry holds the root builder of every named connection
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]*Builder)
+)
+
+// New returns a root builder for db that shares no state with Set/DB or
+// the registry. Like DB(), start every query from a fresh copy: call New
+// again or Clone the returned builder.
+func New(db *sql.DB, opts ...Option) *Builder {
+	return newBuilder(db, opts...)
+}
+
+// Register adds a named connection, replacing any connection already
+// registered under name. Queries on it start with Use(name).
+func Register(name string, db *sql.DB, opts ...Option) {
+	root := newBuilder(db, opts...)
+
+	registryMu.Lock()
+	registry[name] = root
+	registryMu.Unlock()
+}
+
+// Use returns a fresh builder for the connection registered as name
+func Use(name string) *Builder {
+	registryMu.RLock()
+	root, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		panic(fmt.Sprintf("gsorm: no connection registered as %q. Call Register() first.", name))
+	}
+	// Return clone to avoid state sharing
+	return root.Clone()
+}
+
+// Set initializes the default connection. Only the first call takes
+// effect; later calls return the existing root builder.
+func Set(db *sql.DB, opts ...Option) *Builder {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if root, ok := registry[DefaultConnection]; ok {
+		return root
+	}
+
+	root := newBuilder(db, opts...)
+	registry[DefaultConnection] = root
+	return root
+}
+
+// DB returns a fresh builder for the default connection
+func DB() *Builder {
+	registryMu.RLock()
+	root, ok := registry[DefaultConnection]
+	registryMu.RUnlock()
+
+	if !ok {
+		panic("GSORM not initialized. Call Set() first.")
+	}
+	// Return clone to avoid state sharing
+	return root.Clone()
+}
@@ -0,0 +1,122 @@
+package gsorm
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// openNamedDB opens an in-memory database with a single "items" table
+func openNamedDB(t *testing.T, rows ...string) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	for _, name := range rows {
+		if _, err := db.Exec(`INSERT INTO items (name) VALUES (?)`, name); err != nil {
+			t.Fatalf("Failed to insert row: %v", err)
+		}
+	}
+	return db
+}
+
+func TestNewIsIndependent(t *testing.T) {
+	orders := New(openNamedDB(t, "order-1", "order-2"))
+	analytics := New(openNamedDB(t, "event-1"), WithDialect(SQLite), WithIdentifierQuoting())
+
+	count, err := orders.Clone().Table("items").Count()
+	if err != nil || count != 2 {
+		t.Errorf("Expected 2 orders, got %d (%v)", count, err)
+	}
+
+	count, err = analytics.Clone().Table("items").Count()
+	if err != nil || count != 1 {
+		t.Errorf("Expected 1 event, got %d (%v)", count, err)
+	}
+
+	if orders.quoting || !analytics.quoting {
+		t.Error("Options should only apply to their own instance")
+	}
+}
+
+func TestRegisterAndUse(t *testing.T) {
+	defer resetSingleton()
+
+	Register("orders", openNamedDB(t, "order-1", "order-2"))
+	Register("analytics", openNamedDB(t, "event-1"))
+
+	orders, err := Use("orders").Table("items").OrderBy("id", "ASC").ToArray()
+	if err != nil || len(orders) != 2 || orders[0]["name"] != "order-1" {
+		t.Errorf("Unexpected orders: %v (%v)", orders, err)
+	}
+
+	count, err := Use("analytics").Table("items").Count()
+	if err != nil || count != 1 {
+		t.Errorf("Expected 1 event, got %d (%v)", count, err)
+	}
+
+	// Use returns a fresh builder every time
+	first := Use("orders").Table("items").Where("id", "=", 1)
+	if len(Use("orders").whereConds) != 0 || len(first.whereConds) != 1 {
+		t.Error("Use() should not share state between calls")
+	}
+
+	// Registering again replaces the connection
+	Register("analytics", openNamedDB(t))
+	count, err = Use("analytics").Table("items").Count()
+	if err != nil || count != 0 {
+		t.Errorf("Expected replaced connection to be empty, got %d (%v)", count, err)
+	}
+}
+
+func TestUseUnknownPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Use() should panic for an unregistered name")
+		}
+	}()
+	Use("missing")
+}
+
+func TestSetIsDefaultConnection(t *testing.T) {
+	defer resetSingleton()
+	resetSingleton()
+
+	db := openNamedDB(t, "a")
+	root := Set(db)
+
+	if Set(openNamedDB(t)) != root {
+		t.Error("Only the first Set() should take effect")
+	}
+
+	count, err := Use(DefaultConnection).Table("items").Count()
+	if err != nil || count != 1 {
+		t.Errorf("Expected DB() connection via Use(DefaultConnection), got %d (%v)", count, err)
+	}
+}
+
+func TestRegistryConcurrentUse(t *testing.T) {
+	defer resetSingleton()
+
+	Register("shared", openNamedDB(t, "x"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%5 == 0 {
+				Register(fmt.Sprintf("extra-%d", i), nil)
+			}
+			Use("shared").Table("items").Where("id", "=", i)
+		}(i)
+	}
+	wg.Wait()
+}
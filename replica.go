@@ -0,0 +1,148 @@
+package gsorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicaSelection picks the replica that serves a read
+type ReplicaSelection int
+
+const (
+	// RoundRobin cycles through the replicas in order (the default)
+	RoundRobin ReplicaSelection = iota
+	// Random picks a replica at random for every read
+	Random
+)
+
+// defaultReplicaCooldown is how long an unhealthy replica stays ejected
+const defaultReplicaCooldown = 30 * time.Second
+
+// replicaSet is shared by a root builder and all of its clones
+type replicaSet struct {
+	dbs       []*sql.DB
+	selection ReplicaSelection
+	cooldown  time.Duration
+	next      atomic.Uint64
+
+	mu      sync.Mutex
+	ejected []time.Time // replica i is skipped until ejected[i]
+}
+
+// WithReplicas sends reads (Get, First, Count, ToArray, aggregates, Scan)
+// to the given read replicas. Writes, transactions and OnPrimary queries
+// use the primary connection passed to Set, New or Register.
+func WithReplicas(replicas ...*sql.DB) Option {
+	return func(b *Builder) {
+		set := b.replicaSet()
+		set.dbs = append(set.dbs, replicas...)
+		set.ejected = make([]time.Time, len(set.dbs))
+	}
+}
+
+// WithReplicaSelection sets how a replica is picked for each read
+func WithReplicaSelection(selection ReplicaSelection) Option {
+	return func(b *Builder) {
+		b.replicaSet().selection = selection
+	}
+}
+
+// WithReplicaCooldown sets how long a replica that failed a health check
+// is taken out of rotation before it is tried again (default 30s)
+func WithReplicaCooldown(d time.Duration) Option {
+	return func(b *Builder) {
+		b.replicaSet().cooldown = d
+	}
+}
+
+func (b *Builder) replicaSet() *replicaSet {
+	if b.replicas == nil {
+		b.replicas = &replicaSet{cooldown: defaultReplicaCooldown}
+	}
+	return b.replicas
+}
+
+// OnPrimary sends the reads of this query to the primary, e.g. to read
+// a row right after writing it
+func (b *Builder) OnPrimary() *Builder {
+	b.onPrimary = true
+	return b
+}
+
+// readDB returns the connection that serves a read without retries
+func (b *Builder) readDB() *sql.DB {
+	if b.replicas == nil || b.onPrimary {
+		return b.db
+	}
+	if candidates := b.replicas.candidates(); len(candidates) > 0 {
+		return b.replicas.dbs[candidates[0]]
+	}
+	return b.db
+}
+
+// onReplica runs a read on the replicas. When a replica fails and its
+// health check fails too, it is ejected and the read is retried on the
+// next one, falling back to the primary when none is left.
+func onReplica[T any](b *Builder, run func(*sql.DB) (T, error)) (T, error) {
+	if b.replicas == nil || b.onPrimary {
+		return run(b.db)
+	}
+
+	for _, i := range b.replicas.candidates() {
+		result, err := run(b.replicas.dbs[i])
+		if err == nil || errors.Is(err, sql.ErrNoRows) || !b.replicas.unhealthy(b.Context(), i) {
+			return result, err
+		}
+	}
+	return run(b.db)
+}
+
+// candidates returns the replicas in rotation, starting with the selected one
+func (r *replicaSet) candidates() []int {
+	n := len(r.dbs)
+	if n == 0 {
+		return nil
+	}
+
+	var start int
+	if r.selection == Random {
+		start = rand.Intn(n)
+	} else {
+		start = int((r.next.Add(1) - 1) % uint64(n))
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	candidates := make([]int, 0, n)
+	for k := 0; k < n; k++ {
+		i := (start + k) % n
+		if now.Before(r.ejected[i]) {
+			continue
+		}
+		candidates = append(candidates, i)
+	}
+	return candidates
+}
+
+// unhealthy pings replica i after a failed read and ejects it when the
+// ping fails. Errors caused by the caller's context don't count.
+func (r *replicaSet) unhealthy(ctx context.Context, i int) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err := r.dbs[i].PingContext(ctx); err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	r.mu.Lock()
+	r.ejected[i] = time.Now().Add(r.cooldown)
+	r.mu.Unlock()
+	return true
+}
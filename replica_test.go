@@ -0,0 +1,155 @@
+package gsorm
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openReplicaFile creates a SQLite file whose "nodes" table names the
+// database, so each test can tell which connection served a read
+func openReplicaFile(t *testing.T, name string) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), name+".db"))
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", name, err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+		CREATE TABLE nodes (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL);
+		INSERT INTO nodes (name) VALUES (?);
+	`, name)
+	if err != nil {
+		t.Fatalf("Failed to set up %s: %v", name, err)
+	}
+	return db
+}
+
+// servedBy returns the database name that answered a read
+func servedBy(t *testing.T, b *Builder) string {
+	rows, err := b.Table("nodes").Select("name").OrderBy("id", "ASC").Limit(1).ToArray()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	return rows[0]["name"].(string)
+}
+
+func TestReplicaRoundRobin(t *testing.T) {
+	primary := openReplicaFile(t, "primary")
+	root := New(primary, WithReplicas(openReplicaFile(t, "replica1"), openReplicaFile(t, "replica2")))
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, servedBy(t, root.Clone()))
+	}
+
+	expected := []string{"replica1", "replica2", "replica1", "replica2"}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("Expected reads %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestReplicaRandom(t *testing.T) {
+	root := New(openReplicaFile(t, "primary"),
+		WithReplicas(openReplicaFile(t, "replica1"), openReplicaFile(t, "replica2")),
+		WithReplicaSelection(Random))
+
+	for i := 0; i < 10; i++ {
+		if name := servedBy(t, root.Clone()); name != "replica1" && name != "replica2" {
+			t.Fatalf("Random read served by %s", name)
+		}
+	}
+}
+
+func TestReplicaWritesAndTransactionsUsePrimary(t *testing.T) {
+	primary := openReplicaFile(t, "primary")
+	replica := openReplicaFile(t, "replica1")
+	root := New(primary, WithReplicas(replica))
+
+	if _, err := root.Clone().Table("nodes").Insert(map[string]interface{}{"name": "written"}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	var count int
+	primary.QueryRow(`SELECT COUNT(*) FROM nodes`).Scan(&count)
+	if count != 2 {
+		t.Errorf("Expected insert on primary, primary has %d rows", count)
+	}
+
+	// Replica reads don't see the write, OnPrimary does
+	if n, _ := root.Clone().Table("nodes").Count(); n != 1 {
+		t.Errorf("Expected replica count 1, got %d", n)
+	}
+	if n, _ := root.Clone().OnPrimary().Table("nodes").Count(); n != 2 {
+		t.Errorf("Expected primary count 2, got %d", n)
+	}
+
+	err := root.Clone().WithTransaction(func(tx *Builder) error {
+		if name := servedBy(t, tx.Clone()); name != "primary" {
+			t.Errorf("Expected transaction read on primary, got %s", name)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction failed: %v", err)
+	}
+}
+
+func TestReplicaEjectAndRetry(t *testing.T) {
+	primary := openReplicaFile(t, "primary")
+	broken := openReplicaFile(t, "replica1")
+	healthy := openReplicaFile(t, "replica2")
+	root := New(primary, WithReplicas(broken, healthy), WithReplicaCooldown(50*time.Millisecond))
+
+	broken.Close()
+
+	// The failed read is retried on the healthy replica
+	for i := 0; i < 3; i++ {
+		if name := servedBy(t, root.Clone()); name != "replica2" {
+			t.Fatalf("Expected read retried on replica2, got %s", name)
+		}
+	}
+
+	if n, err := root.Clone().Table("nodes").Count(); err != nil || n != 1 {
+		t.Errorf("Count should be served by a healthy replica, got %d (%v)", n, err)
+	}
+
+	// Only the broken replica is ejected
+	root.replicas.mu.Lock()
+	brokenEjected := time.Now().Before(root.replicas.ejected[0])
+	healthyEjected := time.Now().Before(root.replicas.ejected[1])
+	root.replicas.mu.Unlock()
+	if !brokenEjected || healthyEjected {
+		t.Errorf("Expected only replica1 to be ejected, got %v/%v", brokenEjected, healthyEjected)
+	}
+
+	// After the cooldown the replica is tried again
+	time.Sleep(60 * time.Millisecond)
+	if candidates := root.replicas.candidates(); len(candidates) != 2 {
+		t.Errorf("Expected replica back in rotation after cooldown, got %v", candidates)
+	}
+}
+
+func TestReplicaFallbackToPrimary(t *testing.T) {
+	primary := openReplicaFile(t, "primary")
+	replica := openReplicaFile(t, "replica1")
+	root := New(primary, WithReplicas(replica))
+
+	replica.Close()
+
+	if name := servedBy(t, root.Clone()); name != "primary" {
+		t.Errorf("Expected fallback to primary, got %s", name)
+	}
+
+	// Query errors on a healthy replica are returned, not retried
+	replicas := New(primary, WithReplicas(openReplicaFile(t, "replica2")))
+	if _, err := replicas.Clone().Table("missing").ToArray(); err == nil {
+		t.Error("Expected error for missing table")
+	}
+	if candidates := replicas.replicas.candidates(); len(candidates) != 1 {
+		t.Error("A query error should not eject a healthy replica")
+	}
+}
@@ -0,0 +1,290 @@
+package gsorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrReturningUnsupported is returned when a write can't return the rows it
+// wrote on the active dialect (see ReturningQuery)
+var ErrReturningUnsupported = errors.New("gsorm: dialect can't return written rows")
+
+// returningSpec collects the rows returned by one write of a
+// ReturningQuery. It is shared by the clones running its batches.
+type returningSpec struct {
+	cols []string // quoted, or "*"
+	key  string   // auto-increment key for the LAST_INSERT_ID fallback
+
+	dest    reflect.Value // slice or struct to scan into; invalid for maps
+	scanned bool          // struct dest holds a row
+	maps    []map[string]interface{}
+}
+
+// ReturningQuery runs a write and returns the rows it wrote, using
+// RETURNING (PostgreSQL, SQLite 3.35+) or OUTPUT INSERTED/DELETED
+// (SQL Server). Dialects without either (MySQL) read a single inserted row
+// back by its auto-increment key from LAST_INSERT_ID(); other writes,
+// including inserts of several rows, fail with ErrReturningUnsupported
+// before running. Writes with RETURNING always
+// run on the primary.
+type ReturningQuery struct {
+	b    *Builder
+	spec *returningSpec
+}
+
+// Returning starts a write that returns cols of the written rows, e.g.
+// generated IDs, defaults and timestamps. Without cols every column is
+// returned.
+func (b *Builder) Returning(cols ...string) *ReturningQuery {
+	spec := &returningSpec{key: b.ident("id")}
+	if len(cols) == 0 {
+		spec.cols = []string{"*"}
+	}
+	for _, col := range cols {
+		spec.cols = append(spec.cols, b.ident(col))
+	}
+	return &ReturningQuery{b: b, spec: spec}
+}
+
+// Key sets the auto-increment column used to read inserted rows back on
+// dialects without RETURNING (default "id")
+func (q *ReturningQuery) Key(col string) *ReturningQuery {
+	q.spec.key = q.b.ident(col)
+	return q
+}
+
+// Into scans the returned rows into dest, a pointer to a slice of structs
+// (every row) or to a struct (first row), instead of returning maps
+func (q *ReturningQuery) Into(dest interface{}) *ReturningQuery {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() ||
+		(v.Elem().Kind() != reflect.Slice && v.Elem().Kind() != reflect.Struct) {
+		q.b.setErr(fmt.Errorf("gsorm: Into expects a pointer to a struct or slice, got %T", dest))
+		return q
+	}
+	q.spec.dest = v.Elem()
+	return q
+}
+
+// Insert inserts a row and returns it
+func (q *ReturningQuery) Insert(data map[string]interface{}) ([]map[string]interface{}, error) {
+	return q.write(func(b *Builder) error {
+		_, err := b.Insert(data)
+		return err
+	})
+}
+
+// InsertBulk inserts rows and returns them, in insertion order
+func (q *ReturningQuery) InsertBulk(data []map[string]interface{}) ([]map[string]interface{}, error) {
+	if clause, _ := q.b.dialect.Returning(KindInsert, q.spec.cols); clause == "" && len(data) > 1 {
+		// The keys of a multi-row insert needn't be consecutive
+		return nil, ErrReturningUnsupported
+	}
+	return q.write(func(b *Builder) error {
+		_, err := b.InsertBulk(data)
+		return err
+	})
+}
+
+// Update updates the matching rows and returns their new values
+func (q *ReturningQuery) Update(data map[string]interface{}) ([]map[string]interface{}, error) {
+	return q.write(func(b *Builder) error {
+		_, err := b.Update(data)
+		return err
+	})
+}
+
+// UpdateBulk updates rows by their keys and returns their new values
+func (q *ReturningQuery) UpdateBulk(updates []map[string]interface{}, keyColumns ...string) ([]map[string]interface{}, error) {
+	return q.write(func(b *Builder) error {
+		_, err := b.UpdateBulk(updates, keyColumns...)
+		return err
+	})
+}
+
+// Delete deletes the matching rows and returns them
+func (q *ReturningQuery) Delete() ([]map[string]interface{}, error) {
+	return q.write(func(b *Builder) error {
+		_, err := b.Delete()
+		return err
+	})
+}
+
+// CreateOrUpdate upserts a row and returns the inserted or updated row
+func (q *ReturningQuery) CreateOrUpdate(data map[string]interface{}, conflictColumns []string) ([]map[string]interface{}, error) {
+	return q.write(func(b *Builder) error {
+		_, err := b.CreateOrUpdate(data, conflictColumns)
+		return err
+	})
+}
+
+// UpsertBulk upserts rows and returns the inserted or updated rows
+func (q *ReturningQuery) UpsertBulk(rows []map[string]interface{}, conflictCols, updateCols []string) ([]map[string]interface{}, error) {
+	return q.write(func(b *Builder) error {
+		_, err := b.UpsertBulk(rows, conflictCols, updateCols)
+		return err
+	})
+}
+
+// write runs fn with the spec attached to the builder for this write only,
+// so each call returns just its own rows and later plain writes on the
+// builder run without RETURNING. A struct dest with no returned row
+// reports sql.ErrNoRows.
+func (q *ReturningQuery) write(fn func(b *Builder) error) ([]map[string]interface{}, error) {
+	spec := q.spec
+	spec.maps = nil
+	spec.scanned = false
+	if spec.dest.IsValid() && spec.dest.Kind() == reflect.Slice {
+		spec.dest.SetLen(0)
+	}
+
+	prev := q.b.returning
+	q.b.returning = spec
+	err := fn(q.b)
+	q.b.returning = prev
+
+	if err != nil {
+		return nil, err
+	}
+	if spec.dest.IsValid() && spec.dest.Kind() == reflect.Struct && !spec.scanned {
+		return nil, sql.ErrNoRows
+	}
+	return spec.maps, nil
+}
+
+// returningClause returns the OUTPUT clause (before VALUES/WHERE) and the
+// trailing RETURNING clause of a write of kind, both empty without Returning
+func (b *Builder) returningClause(kind QueryKind) (output, trailing string) {
+	if b.returning == nil {
+		return "", ""
+	}
+	clause, isOutput := b.dialect.Returning(kind, b.returning.cols)
+	if isOutput {
+		return clause, ""
+	}
+	return "", clause
+}
+
+// returningCols returns the columns an upsert returns, nil without Returning
+func (b *Builder) returningCols() []string {
+	if b.returning == nil {
+		return nil
+	}
+	return b.returning.cols
+}
+
+// execReturning runs a write built with a RETURNING/OUTPUT clause as a query
+// on the primary and collects the rows. Without a clause on the dialect,
+// single row inserts are run and read back; other writes fail.
+func (b *Builder) execReturning(kind QueryKind, query string, args []interface{}) (sql.Result, error) {
+	if clause, _ := b.dialect.Returning(kind, b.returning.cols); clause == "" {
+		if kind != KindInsert {
+			return nil, ErrReturningUnsupported
+		}
+		return b.insertReadBack(query, args)
+	}
+
+	var n int64
+	err := b.run(b.Context(), kind, rebind(b.dialect, query), args, func(ctx context.Context, query string, args []interface{}) (int64, error) {
+		// queryContext uses the transaction if any, otherwise db: the primary
+		rows, err := b.queryContext(ctx, b.db, query, args)
+		if err != nil {
+			return -1, err
+		}
+		defer rows.Close()
+
+		n, err = b.returning.collect(rows)
+		return n, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return returnedResult(n), nil
+}
+
+// insertReadBack runs a single row insert on a dialect without RETURNING
+// and selects the inserted row by its LAST_INSERT_ID() key
+func (b *Builder) insertReadBack(query string, args []interface{}) (sql.Result, error) {
+	spec := b.returning
+	b.returning = nil
+	result, err := b.exec(KindInsert, query, args)
+	b.returning = spec
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return result, err
+	}
+
+	cols := spec.cols[0]
+	for _, col := range spec.cols[1:] {
+		cols += ", " + col
+	}
+	read := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", cols, b.table, spec.key)
+
+	err = b.run(b.Context(), KindSelect, rebind(b.dialect, read), []interface{}{id}, func(ctx context.Context, query string, args []interface{}) (int64, error) {
+		rows, err := b.queryContext(ctx, b.db, query, args)
+		if err != nil {
+			return -1, err
+		}
+		defer rows.Close()
+
+		_, err = spec.collect(rows)
+		return -1, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// collect adds rows to the spec's destination and returns how many there were
+func (spec *returningSpec) collect(rows *sql.Rows) (int64, error) {
+	if !spec.dest.IsValid() {
+		maps, err := scanMaps(rows)
+		spec.maps = append(spec.maps, maps...)
+		return int64(len(maps)), err
+	}
+
+	if spec.dest.Kind() == reflect.Slice {
+		before := spec.dest.Len()
+		err := scanRows(rows, spec.dest)
+		return int64(spec.dest.Len() - before), err
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for rows.Next() {
+		if !spec.scanned {
+			if err := scanStruct(rows, columns, spec.dest); err != nil {
+				return n, err
+			}
+			spec.scanned = true
+		}
+		n++
+	}
+	return n, rows.Err()
+}
+
+// returnedResult is the sql.Result of a write run with RETURNING
+type returnedResult int64
+
+func (r returnedResult) LastInsertId() (int64, error) {
+	return 0, errors.New("gsorm: LastInsertId is not available with Returning, read the returned rows")
+}
+
+func (r returnedResult) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
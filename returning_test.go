@@ -0,0 +1,235 @@
+package gsorm
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type returnedTask struct {
+	ID       int64  `db:"id"`
+	Title    string `db:"title"`
+	Status   string `db:"status"`
+	Priority int    `db:"priority"`
+}
+
+func TestReturningInsert(t *testing.T) {
+	root := New(openTasksDB(t))
+
+	rows, err := root.Clone().Table("tasks").Returning("id", "status").Insert(map[string]interface{}{"title": "a"})
+	if err != nil {
+		t.Fatalf("Insert() failed: %v", err)
+	}
+	if len(rows) != 1 || rows[0]["id"] != int64(1) || rows[0]["status"] != "open" {
+		t.Errorf("Expected generated id and default status, got %v", rows)
+	}
+
+	// Batches of a bulk insert return their rows in order
+	rows, err = root.Clone().Table("tasks").BatchSize(1).Returning("id", "title").InsertBulk([]map[string]interface{}{
+		{"title": "b"}, {"title": "c"}, {"title": "d"},
+	})
+	if err != nil {
+		t.Fatalf("InsertBulk() failed: %v", err)
+	}
+	var ids []interface{}
+	for _, row := range rows {
+		ids = append(ids, row["id"])
+	}
+	if !reflect.DeepEqual(ids, []interface{}{int64(2), int64(3), int64(4)}) {
+		t.Errorf("Expected ids 2, 3, 4, got %v", ids)
+	}
+
+	// Without columns every column is returned
+	rows, err = root.Clone().Table("tasks").Returning().Insert(map[string]interface{}{"title": "e", "priority": 3})
+	if err != nil || len(rows) != 1 || len(rows[0]) != 4 || rows[0]["priority"] != int64(3) {
+		t.Errorf("Expected all columns, got %v (%v)", rows, err)
+	}
+}
+
+func TestReturningUpdateDeleteUpsert(t *testing.T) {
+	root := New(openTasksDB(t))
+	root.Clone().Table("tasks").InsertBulk([]map[string]interface{}{
+		{"title": "a"}, {"title": "b"}, {"title": "c"},
+	})
+
+	rows, err := root.Clone().Table("tasks").Where("id", "<=", 2).Returning("id", "status").Update(map[string]interface{}{"status": "done"})
+	if err != nil || len(rows) != 2 || rows[1]["status"] != "done" {
+		t.Errorf("Expected 2 updated rows, got %v (%v)", rows, err)
+	}
+
+	rows, err = root.Clone().Table("tasks").Where("id", "=", 3).Returning("title").Delete()
+	if err != nil || len(rows) != 1 || rows[0]["title"] != "c" {
+		t.Errorf("Expected deleted row, got %v (%v)", rows, err)
+	}
+
+	rows, err = root.Clone().Table("tasks").Returning("id", "title").
+		CreateOrUpdate(map[string]interface{}{"id": 1, "title": "renamed"}, []string{"id"})
+	if err != nil || len(rows) != 1 || rows[0]["title"] != "renamed" {
+		t.Errorf("Expected upserted row, got %v (%v)", rows, err)
+	}
+}
+
+func TestReturningInto(t *testing.T) {
+	root := New(openTasksDB(t))
+
+	var task returnedTask
+	rows, err := root.Clone().Table("tasks").Returning().Into(&task).Insert(map[string]interface{}{"title": "a"})
+	if err != nil || rows != nil {
+		t.Fatalf("Insert() failed: %v %v", rows, err)
+	}
+	if task.ID != 1 || task.Title != "a" || task.Status != "open" || task.Priority != 1 {
+		t.Errorf("Unexpected task %+v", task)
+	}
+
+	var tasks []returnedTask
+	_, err = root.Clone().Table("tasks").BatchSize(1).Returning("id", "title").Into(&tasks).
+		InsertBulk([]map[string]interface{}{{"title": "b"}, {"title": "c"}})
+	if err != nil || len(tasks) != 2 || tasks[1].ID != 3 || tasks[1].Title != "c" {
+		t.Errorf("Unexpected tasks %+v (%v)", tasks, err)
+	}
+
+	_, err = root.Clone().Table("tasks").Where("id", "=", 99).Returning().Into(&task).Delete()
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
+	}
+
+	if _, err := root.Clone().Table("tasks").Returning().Into(task).Delete(); err == nil {
+		t.Error("Expected error for non-pointer destination")
+	}
+}
+
+func TestReturningScopedToOneWrite(t *testing.T) {
+	root := New(openTasksDB(t))
+	b := root.Clone().Table("tasks")
+	q := b.Returning("id")
+
+	first, err := q.Insert(map[string]interface{}{"title": "a"})
+	if err != nil {
+		t.Fatalf("Insert() failed: %v", err)
+	}
+	second, err := q.Insert(map[string]interface{}{"title": "b"})
+	if err != nil {
+		t.Fatalf("Insert() failed: %v", err)
+	}
+	if len(first) != 1 || len(second) != 1 || second[0]["id"] != int64(2) {
+		t.Errorf("Expected each call to return its own row, got %v %v", first, second)
+	}
+
+	// A plain write on the builder afterwards doesn't use RETURNING
+	result, err := b.Insert(map[string]interface{}{"title": "c"})
+	if err != nil {
+		t.Fatalf("Insert() failed: %v", err)
+	}
+	if id, err := result.LastInsertId(); err != nil || id != 3 {
+		t.Errorf("Expected LastInsertId 3, got %d (%v)", id, err)
+	}
+}
+
+func TestReturningUsesPrimary(t *testing.T) {
+	primary := openReplicaFile(t, "primary")
+	root := New(primary, WithReplicas(openReplicaFile(t, "replica1")))
+
+	rows, err := root.Clone().Table("nodes").Returning("id").Insert(map[string]interface{}{"name": "written"})
+	if err != nil || len(rows) != 1 || rows[0]["id"] != int64(2) {
+		t.Fatalf("Expected id 2 from the primary, got %v (%v)", rows, err)
+	}
+
+	var count int
+	primary.QueryRow(`SELECT COUNT(*) FROM nodes`).Scan(&count)
+	if count != 2 {
+		t.Errorf("Expected the insert on the primary, primary has %d rows", count)
+	}
+}
+
+func TestReturningSQL(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		run     func(*Builder)
+		want    string
+	}{
+		{
+			Postgres,
+			func(b *Builder) { b.Table("tasks").Returning("id").Insert(map[string]interface{}{"title": "a"}) },
+			`INSERT INTO tasks (title) VALUES ($1) RETURNING id`,
+		},
+		{
+			Postgres,
+			func(b *Builder) {
+				b.Table("tasks").Returning("id").CreateOrUpdate(map[string]interface{}{"id": 1, "title": "a"}, []string{"id"})
+			},
+			`INSERT INTO tasks (id, title) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET title = excluded.title RETURNING id`,
+		},
+		{
+			SQLServer,
+			func(b *Builder) {
+				b.Table("tasks").Returning("id", "title").InsertBulk([]map[string]interface{}{{"title": "a"}, {"title": "b"}})
+			},
+			`INSERT INTO tasks (title) OUTPUT INSERTED.id, INSERTED.title VALUES (@p1), (@p2)`,
+		},
+		{
+			SQLServer,
+			func(b *Builder) {
+				b.Table("tasks").Where("id", "=", 1).Returning("status").Update(map[string]interface{}{"status": "done"})
+			},
+			`UPDATE tasks SET status = @p1 OUTPUT INSERTED.status WHERE id = @p2`,
+		},
+		{
+			SQLServer,
+			func(b *Builder) { b.Table("tasks").Where("id", "=", 1).Returning().Delete() },
+			`DELETE FROM tasks OUTPUT DELETED.* WHERE id = @p1`,
+		},
+		{
+			SQLServer,
+			func(b *Builder) {
+				b.Table("tasks").Returning("id").CreateOrUpdate(map[string]interface{}{"id": 1, "title": "a"}, []string{"id"})
+			},
+			`MERGE INTO tasks AS target USING (VALUES (@p1, @p2)) AS source (id, title) ON target.id = source.id ` +
+				`WHEN MATCHED THEN UPDATE SET target.title = source.title WHEN NOT MATCHED THEN INSERT (id, title) VALUES (source.id, source.title) OUTPUT INSERTED.id;`,
+		},
+	}
+
+	for _, tt := range tests {
+		var calls []string
+		hook := &recordHook{name: "rec", calls: &calls}
+		tt.run(New(openTasksDB(t), WithDialect(tt.dialect), WithHooks(hook, denyHook{})))
+
+		if len(hook.events) != 1 || hook.events[0].SQL != tt.want {
+			t.Errorf("[%s] expected %s, got %v", tt.dialect.Name(), tt.want, hook.events)
+		}
+	}
+}
+
+func TestReturningFallback(t *testing.T) {
+	// MySQL has no RETURNING: inserts are read back by their key
+	var calls []string
+	hook := &recordHook{name: "rec", calls: &calls}
+	root := New(openTasksDB(t), WithDialect(MySQL), WithHooks(hook))
+
+	rows, err := root.Clone().Table("tasks").Returning("id", "status").Insert(map[string]interface{}{"title": "a"})
+	if err != nil || len(rows) != 1 || rows[0]["id"] != int64(1) || rows[0]["status"] != "open" {
+		t.Fatalf("Expected the inserted row read back, got %v (%v)", rows, err)
+	}
+	if len(hook.events) != 2 || hook.events[1].SQL != "SELECT id, status FROM tasks WHERE id = ?" {
+		t.Errorf("Unexpected read back: %v", hook.events)
+	}
+
+	// Keys of a multi-row insert may interleave with other sessions' rows
+	hook.events = nil
+	_, err = root.Clone().Table("tasks").Returning("id").InsertBulk([]map[string]interface{}{{"title": "b"}, {"title": "c"}})
+	if !errors.Is(err, ErrReturningUnsupported) {
+		t.Errorf("Expected ErrReturningUnsupported, got %v", err)
+	}
+	if len(hook.events) != 0 {
+		t.Error("Unsupported multi-row inserts should not run")
+	}
+
+	hook.events = nil
+	_, err = root.Clone().Table("tasks").Where("id", "=", 1).Returning("id").Update(map[string]interface{}{"status": "done"})
+	if !errors.Is(err, ErrReturningUnsupported) {
+		t.Errorf("Expected ErrReturningUnsupported, got %v", err)
+	}
+	if len(hook.events) != 0 {
+		t.Error("Unsupported writes should not run")
+	}
+}
@@ -0,0 +1,119 @@
+package gsorm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// Scan scans the query results into dest, which must be a pointer to a
+// struct (first row) or a pointer to a slice of structs (all rows)
+func (b *Builder) Scan(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Slice {
+		return b.ScanAll(dest)
+	}
+	return b.ScanOne(dest)
+}
+
+// ScanAll scans every row into dest, a pointer to a slice of structs or
+// struct pointers
+func (b *Builder) ScanAll(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("gsorm: ScanAll expects a pointer to a slice, got %T", dest)
+	}
+
+	rows, err := b.Get()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return scanRows(rows, v.Elem())
+}
+
+// ScanOne scans the first row into dest, a pointer to a struct.
+// Returns sql.ErrNoRows when the query has no results.
+func (b *Builder) ScanOne(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("gsorm: ScanOne expects a pointer to a struct, got %T", dest)
+	}
+
+	b.limitVal = 1
+	rows, err := b.Get()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	if err := scanStruct(rows, columns, v.Elem()); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+// scanRows appends every row of rows to slice
+func scanRows(rows *sql.Rows, slice reflect.Value) error {
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	structType := elemType
+	if isPtr {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("gsorm: cannot scan into slice of %s", elemType)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		item := reflect.New(structType)
+		if err := scanStruct(rows, columns, item.Elem()); err != nil {
+			return err
+		}
+
+		if isPtr {
+			slice.Set(reflect.Append(slice, item))
+		} else {
+			slice.Set(reflect.Append(slice, item.Elem()))
+		}
+	}
+
+	return rows.Err()
+}
+
+// scanStruct scans the current row into the struct value v
+func scanStruct(rows *sql.Rows, columns []string, v reflect.Value) error {
+	info := getStructInfo(v.Type())
+	targets := make([]interface{}, len(columns))
+
+	for i, col := range columns {
+		fi := info.lookup(col)
+		if fi == nil {
+			// Columns without a matching field are discarded
+			var discard interface{}
+			targets[i] = &discard
+			continue
+		}
+		targets[i] = fieldByIndex(v, fi.Index).Addr().Interface()
+	}
+
+	return rows.Scan(targets...)
+}
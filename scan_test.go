@@ -0,0 +1,173 @@
+package gsorm
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type scanTimestamps struct {
+	CreatedAt time.Time
+}
+
+type scanUser struct {
+	ID       int64  `gsorm:"id"`
+	FullName string `db:"name"`
+	Email    *string
+	Age      sql.NullInt64
+	Ignored  string `gsorm:"-"`
+	scanTimestamps
+}
+
+func TestToSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"ID":         "id",
+		"UserID":     "user_id",
+		"CreatedAt":  "created_at",
+		"HTTPServer": "http_server",
+		"Address2":   "address2",
+		"name":       "name",
+	}
+
+	for input, expected := range tests {
+		if got := toSnakeCase(input); got != expected {
+			t.Errorf("toSnakeCase(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestStructInfoCached(t *testing.T) {
+	typ := reflect.TypeOf(scanUser{})
+	first := getStructInfo(typ)
+	second := getStructInfo(typ)
+
+	if first != second {
+		t.Error("Struct metadata should be cached per type")
+	}
+
+	expected := []string{"id", "name", "email", "age", "created_at"}
+	if len(first.Fields) != len(expected) {
+		t.Fatalf("Expected %d fields, got %d", len(expected), len(first.Fields))
+	}
+
+	for i, col := range expected {
+		if first.Fields[i].Column != col {
+			t.Errorf("Expected column '%s', got '%s'", col, first.Fields[i].Column)
+		}
+	}
+}
+
+func TestScanAll(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	var users []scanUser
+	err := DB().Table("users").Where("age", ">", 25).OrderBy("age", "ASC").ScanAll(&users)
+	if err != nil {
+		t.Fatalf("ScanAll() failed: %v", err)
+	}
+
+	if len(users) != 3 {
+		t.Fatalf("Expected 3 users, got %d", len(users))
+	}
+
+	first := users[0]
+	if first.FullName != "Alice Brown" || first.Email == nil || *first.Email != "alice@example.com" {
+		t.Errorf("First user not scanned correctly: %+v", first)
+	}
+
+	if !first.Age.Valid || first.Age.Int64 != 28 {
+		t.Errorf("Expected age 28, got %+v", first.Age)
+	}
+
+	if first.ID == 0 || first.CreatedAt.IsZero() {
+		t.Errorf("Expected id and embedded created_at to be set: %+v", first)
+	}
+}
+
+func TestScanAllPointers(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	var users []*scanUser
+	if err := DB().Table("users").Select("id", "name").ScanAll(&users); err != nil {
+		t.Fatalf("ScanAll() failed: %v", err)
+	}
+
+	if len(users) != 4 {
+		t.Errorf("Expected 4 users, got %d", len(users))
+	}
+
+	for _, user := range users {
+		if user.FullName == "" || user.Email != nil {
+			t.Errorf("Only selected columns should be set: %+v", user)
+		}
+	}
+}
+
+type scanUserEmbeddedPtr struct {
+	*scanTimestamps
+	Name string
+}
+
+func TestScanAllUnexportedEmbeddedPointer(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	var users []scanUserEmbeddedPtr
+	if err := DB().Table("users").Select("name", "created_at").ScanAll(&users); err != nil {
+		t.Fatalf("ScanAll() failed: %v", err)
+	}
+
+	if len(users) != 4 || users[0].Name == "" || users[0].scanTimestamps != nil {
+		t.Errorf("Expected the unexported embedded pointer to be skipped: %+v", users)
+	}
+}
+
+func TestScanOne(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	var user scanUser
+	if err := DB().Table("users").Where("email", "=", "bob@example.com").ScanOne(&user); err != nil {
+		t.Fatalf("ScanOne() failed: %v", err)
+	}
+
+	if user.FullName != "Bob Johnson" || user.Age.Int64 != 35 {
+		t.Errorf("User not scanned correctly: %+v", user)
+	}
+
+	err := DB().Table("users").Where("email", "=", "nobody@example.com").ScanOne(&user)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestScanDispatch(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	var users []scanUser
+	if err := DB().Table("users").Scan(&users); err != nil {
+		t.Fatalf("Scan() into slice failed: %v", err)
+	}
+
+	if len(users) != 4 {
+		t.Errorf("Expected 4 users, got %d", len(users))
+	}
+
+	var user scanUser
+	if err := DB().Table("users").OrderBy("id", "DESC").Scan(&user); err != nil {
+		t.Fatalf("Scan() into struct failed: %v", err)
+	}
+
+	if user.FullName != "Alice Brown" {
+		t.Errorf("Expected Alice Brown, got %s", user.FullName)
+	}
+
+	if err := DB().Table("users").Scan(user); err == nil {
+		t.Error("Scan() into non-pointer should fail")
+	}
+}
@@ -0,0 +1,245 @@
+package gsorm
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// fieldInfo describes a struct field mapped to a column
+type fieldInfo struct {
+	Column        string
+	Index         []int
+	Type          reflect.Type
+	PrimaryKey    bool // pk: identifies the row in UpdateStruct and Find
+	AutoIncrement bool // autoincrement: generated by the database, written back after insert
+	OmitEmpty     bool // omitempty: skipped on write when zero
+	ReadOnly      bool // readonly: never written
+}
+
+// structInfo holds the column mapping of a struct type
+type structInfo struct {
+	Type    reflect.Type
+	Table   string
+	Fields  []*fieldInfo
+	columns map[string]*fieldInfo
+}
+
+// Tabler is implemented by models that override their table name
+type Tabler interface {
+	TableName() string
+}
+
+// structCache caches structInfo per type so reflection is paid once
+var structCache sync.Map // map[reflect.Type]*structInfo
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// getStructInfo returns the cached column mapping for struct type t
+func getStructInfo(t reflect.Type) *structInfo {
+	if cached, ok := structCache.Load(t); ok {
+		return cached.(*structInfo)
+	}
+
+	info := &structInfo{
+		Type:    t,
+		Table:   tableName(t),
+		columns: make(map[string]*fieldInfo),
+	}
+	collectFields(info, t, nil)
+
+	// Without an explicit pk tag an integer "id" column is the
+	// auto-increment primary key
+	if info.PrimaryKey() == nil {
+		if fi := info.columns["id"]; fi != nil {
+			fi.PrimaryKey = true
+			switch fi.Type.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				fi.AutoIncrement = true
+			}
+		}
+	}
+
+	cached, _ := structCache.LoadOrStore(t, info)
+	return cached.(*structInfo)
+}
+
+// collectFields walks t (following embedded structs) and registers mapped fields
+func collectFields(info *structInfo, t reflect.Type, parent []int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, ok := parseTag(field)
+		if !ok {
+			continue
+		}
+
+		index := make([]int, len(parent)+1)
+		copy(index, parent)
+		index[len(parent)] = i
+
+		// Flatten untagged embedded structs into the parent
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				// Like encoding/json: a nil unexported pointer can't be
+				// allocated when scanning, so its fields are skipped
+				if !field.IsExported() {
+					continue
+				}
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !isScalarStruct(ft) {
+				collectFields(info, ft, index)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = toSnakeCase(field.Name)
+		}
+
+		// Outer fields win over promoted ones with the same column
+		if _, exists := info.columns[name]; exists {
+			continue
+		}
+
+		fi := &fieldInfo{
+			Column:        name,
+			Index:         index,
+			Type:          field.Type,
+			PrimaryKey:    opts["pk"] || opts["primarykey"],
+			AutoIncrement: opts["autoincrement"] || opts["auto"],
+			OmitEmpty:     opts["omitempty"],
+			ReadOnly:      opts["readonly"],
+		}
+		info.Fields = append(info.Fields, fi)
+		info.columns[name] = fi
+	}
+}
+
+// parseTag reads the column and options from the gsorm or db tag,
+// e.g. `gsorm:"id,pk,autoincrement"`. Returns false when the field is
+// excluded with "-".
+func parseTag(field reflect.StructField) (string, map[string]bool, bool) {
+	tag, ok := field.Tag.Lookup("gsorm")
+	if !ok {
+		tag = field.Tag.Get("db")
+	}
+
+	parts := strings.Split(tag, ",")
+	name := strings.TrimSpace(parts[0])
+	if name == "-" {
+		return "", nil, false
+	}
+
+	opts := make(map[string]bool, len(parts)-1)
+	for _, opt := range parts[1:] {
+		opts[strings.ToLower(strings.TrimSpace(opt))] = true
+	}
+	return name, opts, true
+}
+
+// isScalarStruct reports whether a struct type is a single column value
+// (time.Time, sql.NullString, ...) rather than a group of fields
+func isScalarStruct(t reflect.Type) bool {
+	return t == timeType || reflect.PointerTo(t).Implements(scannerType)
+}
+
+// lookup returns the field mapped to column, if any
+func (s *structInfo) lookup(column string) *fieldInfo {
+	if fi, ok := s.columns[column]; ok {
+		return fi
+	}
+	return s.columns[strings.ToLower(column)]
+}
+
+// Columns returns the mapped column names in field order
+func (s *structInfo) Columns() []string {
+	cols := make([]string, len(s.Fields))
+	for i, fi := range s.Fields {
+		cols[i] = fi.Column
+	}
+	return cols
+}
+
+// PrimaryKey returns the primary key field, if any
+func (s *structInfo) PrimaryKey() *fieldInfo {
+	for _, fi := range s.Fields {
+		if fi.PrimaryKey {
+			return fi
+		}
+	}
+	return nil
+}
+
+// tableName resolves the table of t from TableName() or its pluralised
+// snake_case type name
+func tableName(t reflect.Type) string {
+	// The pointer method set also covers value receivers
+	if tabler, ok := reflect.New(t).Interface().(Tabler); ok {
+		return tabler.TableName()
+	}
+	return pluralize(toSnakeCase(t.Name()))
+}
+
+// pluralize applies simple English plural rules (user -> users, category -> categories)
+func pluralize(name string) string {
+	switch {
+	case name == "":
+		return name
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsAny(name[len(name)-2:len(name)-1], "aeiou"):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
+
+// fieldByIndex returns the field at index, allocating nil embedded pointers
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// toSnakeCase converts a Go identifier to snake_case (UserID -> user_id)
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					sb.WriteByte('_')
+				}
+			}
+			sb.WriteRune(unicode.ToLower(r))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
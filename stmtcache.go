@@ -0,0 +1,259 @@
+package gsorm
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// StatementCacheStats reports the activity of the prepared statement cache
+type StatementCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Open      int // statements currently cached
+}
+
+// stmtKey identifies a prepared statement: the same SQL is prepared once
+// per connection pool (primary and each replica)
+type stmtKey struct {
+	db    *sql.DB
+	query string
+}
+
+type stmtEntry struct {
+	key     stmtKey
+	stmt    *sql.Stmt
+	refs    int  // statements in flight
+	evicted bool // close once refs drops to zero
+}
+
+// stmtCache is a bounded LRU of prepared statements shared by a root
+// builder and all of its clones
+type stmtCache struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List // front is most recently used
+	entries map[stmtKey]*list.Element
+	closed  bool
+	stats   StatementCacheStats
+}
+
+// WithStatementCache prepares statements once and reuses them, keeping up
+// to size statements per builder tree in an LRU. Inside transactions the
+// cached statement is bound to the transaction with tx.Stmt.
+func WithStatementCache(size int) Option {
+	return func(b *Builder) {
+		if size <= 0 {
+			b.stmts = nil
+			return
+		}
+		b.stmts = &stmtCache{
+			size:    size,
+			lru:     list.New(),
+			entries: make(map[stmtKey]*list.Element),
+		}
+	}
+}
+
+// StatementCacheStats returns the cache hits, misses, evictions and the
+// number of open statements. It is zero when the cache is disabled.
+func (b *Builder) StatementCacheStats() StatementCacheStats {
+	if b.stmts == nil {
+		return StatementCacheStats{}
+	}
+
+	b.stmts.mu.Lock()
+	defer b.stmts.mu.Unlock()
+	stats := b.stmts.stats
+	stats.Open = b.stmts.lru.Len()
+	return stats
+}
+
+// CloseStatements closes all cached statements, e.g. on shutdown before
+// closing the *sql.DB. Statements still in use are closed once they finish.
+// Later queries run unprepared.
+func (b *Builder) CloseStatements() error {
+	if b.stmts == nil {
+		return nil
+	}
+
+	c := b.stmts
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	var firstErr error
+	for el := c.lru.Front(); el != nil; el = el.Next() {
+		if err := c.evict(el.Value.(*stmtEntry)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	c.lru.Init()
+	c.entries = make(map[stmtKey]*list.Element)
+	return firstErr
+}
+
+// lookup returns the cached statement for key, counting a hit or a miss.
+// ok is false when the cache is closed.
+func (c *stmtCache) lookup(key stmtKey) (entry *stmtEntry, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, false
+	}
+	if el, found := c.entries[key]; found {
+		c.stats.Hits++
+		c.lru.MoveToFront(el)
+		entry = el.Value.(*stmtEntry)
+		entry.refs++
+		return entry, true
+	}
+	c.stats.Misses++
+	return nil, true
+}
+
+// add caches a statement prepared after a miss and evicts the least
+// recently used ones beyond the cache size
+func (c *stmtCache) add(key stmtKey, stmt *sql.Stmt) *stmtEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, found := c.entries[key]; found {
+		// Prepared concurrently by another query
+		stmt.Close()
+		entry := el.Value.(*stmtEntry)
+		entry.refs++
+		return entry
+	}
+
+	entry := &stmtEntry{key: key, stmt: stmt, refs: 1}
+	if c.closed {
+		entry.evicted = true
+		return entry
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*stmtEntry).key)
+		c.evict(oldest.Value.(*stmtEntry))
+		c.stats.Evictions++
+	}
+	return entry
+}
+
+// release marks a statement as no longer in flight
+func (c *stmtCache) release(entry *stmtEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// evict closes entry, or defers closing it until it is released.
+// Rows and rows still being scanned keep the statement alive in database/sql.
+func (c *stmtCache) evict(entry *stmtEntry) error {
+	entry.evicted = true
+	if entry.refs == 0 {
+		return entry.stmt.Close()
+	}
+	return nil
+}
+
+// withStmt runs fn with a prepared statement for query when the cache is
+// enabled. ok is false when the cache is closed and the query must run
+// unprepared.
+//
+// Inside a transaction a cached statement is bound to b.tx with tx.Stmt. A
+// miss is prepared on the transaction and not cached, since preparing on
+// db would need a second connection while the transaction holds one.
+func withStmt[T any](b *Builder, ctx context.Context, db *sql.DB, query string, fn func(*sql.Stmt) (T, error)) (result T, ok bool, err error) {
+	if b.stmts == nil {
+		return result, false, nil
+	}
+
+	key := stmtKey{db: db, query: query}
+	entry, ok := b.stmts.lookup(key)
+	if !ok {
+		return result, false, nil
+	}
+
+	if entry == nil {
+		var stmt *sql.Stmt
+		if b.tx != nil {
+			// Closed by database/sql when the transaction ends
+			stmt, err = b.tx.PrepareContext(ctx, query)
+			if err != nil {
+				return result, true, err
+			}
+			result, err = fn(stmt)
+			return result, true, err
+		}
+
+		// Prepare outside the lock so a slow prepare doesn't block other queries
+		stmt, err = db.PrepareContext(ctx, query)
+		if err != nil {
+			return result, true, err
+		}
+		entry = b.stmts.add(key, stmt)
+	}
+	defer b.stmts.release(entry)
+
+	stmt := entry.stmt
+	if b.tx != nil {
+		stmt = b.tx.StmtContext(ctx, stmt)
+	}
+	result, err = fn(stmt)
+	return result, true, err
+}
+
+// execContext runs a write on db or the active transaction
+func (b *Builder) execContext(ctx context.Context, db *sql.DB, query string, args []interface{}) (sql.Result, error) {
+	result, ok, err := withStmt(b, ctx, db, query, func(stmt *sql.Stmt) (sql.Result, error) {
+		return stmt.ExecContext(ctx, args...)
+	})
+	if ok {
+		return result, err
+	}
+	if b.tx != nil {
+		return b.tx.ExecContext(ctx, query, args...)
+	}
+	return db.ExecContext(ctx, query, args...)
+}
+
+// queryContext runs a read on db or the active transaction
+func (b *Builder) queryContext(ctx context.Context, db *sql.DB, query string, args []interface{}) (*sql.Rows, error) {
+	rows, ok, err := withStmt(b, ctx, db, query, func(stmt *sql.Stmt) (*sql.Rows, error) {
+		return stmt.QueryContext(ctx, args...)
+	})
+	if ok {
+		return rows, err
+	}
+	if b.tx != nil {
+		return b.tx.QueryContext(ctx, query, args...)
+	}
+	return db.QueryContext(ctx, query, args...)
+}
+
+// queryRowContext runs a single row read on db or the active transaction.
+// A prepare error is reported when the row is scanned.
+func (b *Builder) queryRowContext(ctx context.Context, db *sql.DB, query string, args []interface{}) *sql.Row {
+	row, ok, err := withStmt(b, ctx, db, query, func(stmt *sql.Stmt) (*sql.Row, error) {
+		return stmt.QueryRowContext(ctx, args...), nil
+	})
+	if ok && err == nil {
+		return row
+	}
+	// Unprepared, or the prepare failed: let database/sql report the error
+	if b.tx != nil {
+		return b.tx.QueryRowContext(ctx, query, args...)
+	}
+	return db.QueryRowContext(ctx, query, args...)
+}
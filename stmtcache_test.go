@@ -0,0 +1,132 @@
+package gsorm
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestStatementCacheHitsAndMisses(t *testing.T) {
+	root := New(openNamedDB(t, "a", "b"), WithStatementCache(8))
+
+	for i := 0; i < 3; i++ {
+		if n, err := root.Clone().Table("items").Where("id", ">", i).Count(); err != nil || n != int64(2-min(i, 2)) {
+			t.Fatalf("Count %d: got %d (%v)", i, n, err)
+		}
+	}
+	if _, err := root.Clone().Table("items").Insert(map[string]interface{}{"name": "c"}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	stats := root.StatementCacheStats()
+	if stats.Misses != 2 || stats.Hits != 2 || stats.Open != 2 {
+		t.Errorf("Expected 2 misses, 2 hits and 2 open statements, got %+v", stats)
+	}
+
+	if stats := New(openNamedDB(t)).StatementCacheStats(); stats != (StatementCacheStats{}) {
+		t.Errorf("Expected zero stats without cache, got %+v", stats)
+	}
+}
+
+func TestStatementCacheEviction(t *testing.T) {
+	root := New(openNamedDB(t, "a"), WithStatementCache(2))
+
+	root.Clone().Table("items").Where("id", "=", 1).Count()
+	root.Clone().Table("items").Where("name", "=", "a").Count()
+	root.Clone().Table("items").Where("id", "=", 1).Count() // hit, now most recent
+	root.Clone().Table("items").Count()                     // evicts the name query
+	root.Clone().Table("items").Where("id", "=", 1).Count() // still cached
+
+	stats := root.StatementCacheStats()
+	if stats.Misses != 3 || stats.Hits != 2 || stats.Evictions != 1 || stats.Open != 2 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestStatementCacheEvictedRowsStayReadable(t *testing.T) {
+	root := New(openReplicaFile(t, "primary"), WithStatementCache(1))
+
+	rows, err := root.Clone().Table("nodes").Get()
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	defer rows.Close()
+
+	// Evict the statement behind rows
+	if _, err := root.Clone().Table("nodes").Count(); err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+
+	if !rows.Next() {
+		t.Fatalf("Expected a row after eviction: %v", rows.Err())
+	}
+}
+
+func TestStatementCacheTransaction(t *testing.T) {
+	root := New(openNamedDB(t, "a"), WithStatementCache(8))
+
+	root.Clone().Table("items").Count()
+
+	// Cached statements are bound to the transaction, misses are prepared on it
+	rollback := errors.New("rollback")
+	err := root.Clone().WithTransaction(func(tx *Builder) error {
+		if _, err := tx.Clone().Table("items").Insert(map[string]interface{}{"name": "b"}); err != nil {
+			return err
+		}
+		if n, err := tx.Clone().Table("items").Count(); err != nil || n != 2 {
+			t.Errorf("Expected 2 rows inside transaction, got %d (%v)", n, err)
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("Expected rollback error, got %v", err)
+	}
+
+	if n, err := root.Clone().Table("items").Count(); err != nil || n != 1 {
+		t.Errorf("Expected rolled back insert, got %d (%v)", n, err)
+	}
+	if stats := root.StatementCacheStats(); stats.Hits != 2 || stats.Misses != 2 || stats.Open != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestStatementCacheClose(t *testing.T) {
+	root := New(openNamedDB(t, "a"), WithStatementCache(8))
+	root.Clone().Table("items").Count()
+
+	if err := root.CloseStatements(); err != nil {
+		t.Fatalf("CloseStatements failed: %v", err)
+	}
+	if stats := root.StatementCacheStats(); stats.Open != 0 {
+		t.Errorf("Expected no open statements, got %+v", stats)
+	}
+
+	// Queries keep working unprepared
+	if n, err := root.Clone().Table("items").Count(); err != nil || n != 1 {
+		t.Errorf("Expected count after close, got %d (%v)", n, err)
+	}
+	if stats := root.StatementCacheStats(); stats.Open != 0 || stats.Misses != 1 {
+		t.Errorf("Closed cache should not prepare statements, got %+v", stats)
+	}
+}
+
+func TestStatementCacheConcurrent(t *testing.T) {
+	root := New(openReplicaFile(t, "primary"), WithStatementCache(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Five distinct statements churn through a cache of two
+			if _, err := root.Clone().Table("nodes").Where("id", ">", i).Limit(i%5 + 1).ToArray(); err != nil {
+				t.Errorf("Query failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := root.CloseStatements(); err != nil {
+		t.Errorf("CloseStatements failed: %v", err)
+	}
+}
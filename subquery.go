@@ -0,0 +1,79 @@
+package gsorm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WhereInSub adds WHERE column IN (SELECT ...) using another builder
+func (b *Builder) WhereInSub(column string, sub *Builder) *Builder {
+	return b.whereSub(b.ident(column), "IN", sub)
+}
+
+// WhereNotInSub adds WHERE column NOT IN (SELECT ...) using another builder
+func (b *Builder) WhereNotInSub(column string, sub *Builder) *Builder {
+	return b.whereSub(b.ident(column), "NOT IN", sub)
+}
+
+// WhereExists adds WHERE EXISTS (SELECT ...) using another builder
+func (b *Builder) WhereExists(sub *Builder) *Builder {
+	return b.whereSub("", "EXISTS", sub)
+}
+
+// WhereNotExists adds WHERE NOT EXISTS (SELECT ...) using another builder
+func (b *Builder) WhereNotExists(sub *Builder) *Builder {
+	return b.whereSub("", "NOT EXISTS", sub)
+}
+
+func (b *Builder) whereSub(column, operator string, sub *Builder) *Builder {
+	b.inherit(sub)
+	b.whereConds = append(b.whereConds, WhereCondition{
+		Column:   column,
+		Operator: operator,
+		Value:    sub.Clone(),
+		Logic:    "AND",
+		kind:     condSub,
+	})
+	return b
+}
+
+// WhereColumn compares two columns, e.g. to correlate a subquery with
+// its outer query: WhereColumn("orders.user_id", "=", "users.id")
+func (b *Builder) WhereColumn(first, operator, second string) *Builder {
+	op := strings.ToUpper(strings.TrimSpace(operator))
+	if kind, ok := operators[op]; !ok || kind != condCompare || op == "ILIKE" {
+		b.setErr(fmt.Errorf("%w: %q between columns", ErrInvalidOperator, operator))
+		return b
+	}
+
+	b.whereConds = append(b.whereConds, WhereCondition{
+		Column:   b.ident(first),
+		Operator: op,
+		Value:    columnRef(b.ident(second)),
+		Logic:    "AND",
+		kind:     condColumn,
+	})
+	return b
+}
+
+// columnRef is a WHERE value naming a column rather than a bound value
+type columnRef string
+
+// SelectSub adds a scalar subquery to the select list as alias.
+// The subquery is rendered when SelectSub is called.
+func (b *Builder) SelectSub(sub *Builder, alias string) *Builder {
+	b.inherit(sub)
+	subQuery, subArgs := sub.buildSelectQuery()
+
+	b.selectCols = append(b.selectCols, "("+subQuery+") AS "+b.ident(alias))
+	b.selectArgs = append(b.selectArgs, subArgs...)
+	return b
+}
+
+// FromSub selects from a derived table: FROM (SELECT ...) AS alias
+func (b *Builder) FromSub(sub *Builder, alias string) *Builder {
+	b.inherit(sub)
+	b.fromSub = sub.Clone()
+	b.table = b.ident(alias)
+	return b
+}
@@ -0,0 +1,187 @@
+package gsorm
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func setupOrdersTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`
+		CREATE TABLE orders (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			amount REAL NOT NULL,
+			status TEXT NOT NULL
+		);
+		INSERT INTO orders (user_id, amount, status) VALUES
+		(1, 100, 'paid'), (1, 50, 'pending'), (2, 300, 'paid'), (3, 20, 'cancelled');
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create orders table: %v", err)
+	}
+}
+
+func TestWhereInSub(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	setupOrdersTable(t, db)
+
+	paid := DB().Table("orders").Select("user_id").Where("status", "=", "paid")
+	builder := DB().Table("users").Select("name").Where("age", ">", 20).WhereInSub("id", paid).OrderBy("name", "ASC")
+
+	query, args := builder.buildSelectQuery()
+
+	expectedQuery := "SELECT name FROM users WHERE age > ? AND id IN (SELECT user_id FROM orders WHERE status = ?) ORDER BY name ASC"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{20, "paid"}) {
+		t.Errorf("Args not correct: %v", args)
+	}
+
+	results, err := builder.ToArray()
+	if err != nil {
+		t.Fatalf("ToArray() failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Errorf("Expected 2 users with paid orders, got %d", len(results))
+	}
+
+	count, err := DB().Table("users").WhereNotInSub("id", paid).Count()
+	if err != nil {
+		t.Fatalf("Count() failed: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("Expected 2 users without paid orders, got %d", count)
+	}
+}
+
+func TestWhereExists(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	setupOrdersTable(t, db)
+
+	orders := DB().Table("orders").Select("1").WhereColumn("orders.user_id", "=", "users.id").Where("amount", ">", 40)
+	builder := DB().Table("users").WhereExists(orders)
+
+	query, _ := builder.buildSelectQuery()
+	expectedQuery := "SELECT * FROM users WHERE EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id AND amount > ?)"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	count, err := builder.Count()
+	if err != nil {
+		t.Fatalf("Count() failed: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("Expected 2 users, got %d", count)
+	}
+
+	count, err = DB().Table("users").WhereNotExists(orders).Count()
+	if err != nil {
+		t.Fatalf("Count() failed: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("Expected 2 users, got %d", count)
+	}
+}
+
+func TestSelectSub(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	setupOrdersTable(t, db)
+
+	total := DB().Table("orders").Select("SUM(amount)").WhereColumn("orders.user_id", "=", "users.id").Where("status", "=", "paid")
+	builder := DB().Table("users").Select("name").SelectSub(total, "paid_total").Where("age", "<", 30).OrderBy("name", "ASC")
+
+	query, args := builder.buildSelectQuery()
+
+	expectedQuery := "SELECT name, (SELECT SUM(amount) FROM orders WHERE orders.user_id = users.id AND status = ?) AS paid_total FROM users WHERE age < ? ORDER BY name ASC"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{"paid", 30}) {
+		t.Errorf("Args not correct: %v", args)
+	}
+
+	results, err := builder.ToArray()
+	if err != nil {
+		t.Fatalf("ToArray() failed: %v", err)
+	}
+
+	if len(results) != 2 || results[1]["paid_total"] != float64(100) {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+
+	count, err := builder.Count()
+	if err != nil {
+		t.Fatalf("Count() failed: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("Count() should ignore select subqueries, got %d", count)
+	}
+}
+
+func TestFromSub(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	setupOrdersTable(t, db)
+
+	totals := DB().Table("orders").
+		Select("user_id", "SUM(amount) AS total").
+		Where("status", "!=", "cancelled").
+		GroupBy("user_id")
+
+	builder := DB().FromSub(totals, "t").Select("user_id", "total").Where("total", ">", 120).OrderBy("total", "DESC")
+
+	query, args := builder.buildSelectQuery()
+
+	expectedQuery := "SELECT user_id, total FROM (SELECT user_id, SUM(amount) AS total FROM orders WHERE status != ? GROUP BY user_id) AS t WHERE total > ? ORDER BY total DESC"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{"cancelled", 120}) {
+		t.Errorf("Args not correct: %v", args)
+	}
+
+	results, err := builder.ToArray()
+	if err != nil {
+		t.Fatalf("ToArray() failed: %v", err)
+	}
+
+	if len(results) != 2 || results[0]["user_id"] != int64(2) {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+}
+
+func TestSubqueryPlaceholderOrder(t *testing.T) {
+	sub := newBuilder(nil, WithDialect(Postgres)).Table("orders").Select("user_id").Where("amount", ">", 10)
+	outer := newBuilder(nil, WithDialect(Postgres)).
+		FromSub(sub.Clone().Where("status", "=", "paid"), "o").
+		Select("user_id").
+		SelectSub(sub, "x").
+		WhereInSub("user_id", sub).
+		Limit(5)
+
+	query, args := outer.buildSelectQuery()
+	query = rebind(Postgres, query)
+
+	expectedQuery := "SELECT user_id, (SELECT user_id FROM orders WHERE amount > $1) AS x FROM (SELECT user_id FROM orders WHERE amount > $2 AND status = $3) AS o WHERE user_id IN (SELECT user_id FROM orders WHERE amount > $4) LIMIT $5"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+
+	if !reflect.DeepEqual(args, []interface{}{10, 10, "paid", 10, 5}) {
+		t.Errorf("Args not correct: %v", args)
+	}
+}
@@ -0,0 +1,131 @@
+package gsorm
+
+import "fmt"
+
+// upsertOptions holds what CreateOrUpdate and UpsertBulk do with a row
+// that conflicts with an existing one
+type upsertOptions struct {
+	update  []string // columns to update, nil for every non-conflict column
+	nothing bool     // keep the existing row
+	where   *Expr    // condition for updating the existing row
+}
+
+// OnConflictUpdate sets the columns CreateOrUpdate and UpsertBulk update
+// on a conflicting row. By default every written column but the conflict
+// columns is updated.
+func (b *Builder) OnConflictUpdate(cols ...string) *Builder {
+	b.upsert.update = cols
+	b.upsert.nothing = false
+	return b
+}
+
+// OnConflictDoNothing keeps conflicting rows as they are, inserting only
+// the rows that don't conflict
+func (b *Builder) OnConflictDoNothing() *Builder {
+	b.upsert.nothing = true
+	return b
+}
+
+// OnConflictWhere updates a conflicting row only when the raw condition
+// holds, e.g. OnConflictWhere("excluded.version > users.version"). The
+// proposed row is "excluded" and the existing row is the table on
+// PostgreSQL and SQLite; they are "source" and "target" on SQL Server.
+// MySQL has no conditional update and fails the upsert.
+func (b *Builder) OnConflictWhere(sql string, args ...interface{}) *Builder {
+	expr := Raw(sql, args...)
+	if b.checkExpr(expr) {
+		b.upsert.where = &expr
+	}
+	return b
+}
+
+// upsertSpec returns the spec of an upsert writing columns and the args of
+// its condition. Without updateColumns the OnConflictUpdate columns, or
+// every column but the conflict columns, are updated.
+func (b *Builder) upsertSpec(columns, conflictColumns, updateColumns []string) (UpsertSpec, []interface{}, error) {
+	spec := UpsertSpec{
+		Table:           b.table,
+		Columns:         make([]string, len(columns)),
+		ConflictColumns: make([]string, len(conflictColumns)),
+		Returning:       b.returningCols(),
+	}
+	for i, col := range columns {
+		spec.Columns[i] = b.ident(col)
+	}
+	for i, col := range conflictColumns {
+		spec.ConflictColumns[i] = b.ident(col)
+	}
+
+	if updateColumns == nil {
+		updateColumns = b.upsert.update
+	}
+	switch {
+	case b.upsert.nothing:
+	case updateColumns == nil:
+		for _, col := range columns {
+			if !containsString(conflictColumns, col) {
+				spec.UpdateColumns = append(spec.UpdateColumns, b.ident(col))
+			}
+		}
+	default:
+		for _, col := range updateColumns {
+			// The new value comes from the proposed row, which only has
+			// the written columns
+			if !containsString(columns, col) {
+				return spec, nil, fmt.Errorf("gsorm: upsert updates column %q, which is not written", col)
+			}
+			spec.UpdateColumns = append(spec.UpdateColumns, b.ident(col))
+		}
+	}
+
+	if b.upsert.where == nil {
+		return spec, nil, nil
+	}
+	if len(spec.UpdateColumns) == 0 {
+		return spec, nil, fmt.Errorf("gsorm: OnConflictWhere requires columns to update")
+	}
+	spec.Where = b.upsert.where.SQL
+	return spec, b.upsert.where.Args, nil
+}
+
+// UpsertBulk inserts rows, updating updateCols of the existing rows they
+// conflict with on conflictCols. Without updateCols the OnConflictUpdate
+// columns, or every column but conflictCols, are updated. Every row must
+// have the same columns, and rows of one statement must not conflict with
+// each other on PostgreSQL and SQL Server. Rows are batched like
+// InsertBulk. It returns the number of rows affected as the driver reports
+// it; MySQL counts an updated row twice.
+func (b *Builder) UpsertBulk(rows []map[string]interface{}, conflictCols, updateCols []string) (int64, error) {
+	if len(rows) == 0 {
+		return 0, b.err
+	}
+
+	columns, uniform := b.bulkColumns(rows)
+	if !uniform {
+		return 0, fmt.Errorf("gsorm: UpsertBulk rows must all have the same columns")
+	}
+
+	spec, whereArgs, err := b.upsertSpec(columns, conflictCols, updateCols)
+	if err != nil {
+		return 0, err
+	}
+
+	value := func(i, j int) interface{} {
+		return rows[i][columns[j]]
+	}
+	return b.writeRows(KindUpsert, columns, len(rows), len(whereArgs), value, func(batch *insertBatch) (string, []interface{}, error) {
+		spec.Values = batch.values.String()
+		query, err := b.dialect.Upsert(spec)
+		return query, append(batch.args, whereArgs...), err
+	})
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
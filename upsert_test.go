@@ -0,0 +1,199 @@
+package gsorm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpsertBulk(t *testing.T) {
+	root := New(openTasksDB(t))
+	root.Clone().Table("tasks").InsertBulk([]map[string]interface{}{
+		{"title": "a", "priority": 1}, {"title": "b", "priority": 5},
+	})
+	tasks := func() []map[string]interface{} {
+		result, err := root.Clone().Table("tasks").Select("id", "title", "status", "priority").OrderBy("id", "ASC").ToArray()
+		if err != nil {
+			t.Fatalf("ToArray() failed: %v", err)
+		}
+		return result
+	}
+
+	// Two statements: updates rows 1 and 2, inserts row 3
+	n, err := root.Clone().Table("tasks").BatchSize(2).UpsertBulk([]map[string]interface{}{
+		{"id": 1, "title": "a2", "status": "done", "priority": 2},
+		{"id": 2, "title": "b2", "status": "done", "priority": 2},
+		{"id": 3, "title": "c", "status": "open", "priority": 3},
+	}, []string{"id"}, []string{"title", "priority"})
+	if err != nil || n != 3 {
+		t.Fatalf("UpsertBulk() = %d, %v", n, err)
+	}
+
+	expected := []map[string]interface{}{
+		{"id": int64(1), "title": "a2", "status": "open", "priority": int64(2)},
+		{"id": int64(2), "title": "b2", "status": "open", "priority": int64(2)},
+		{"id": int64(3), "title": "c", "status": "open", "priority": int64(3)},
+	}
+	if got := tasks(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// Only raise priorities
+	_, err = root.Clone().Table("tasks").OnConflictWhere("excluded.priority > tasks.priority").UpsertBulk([]map[string]interface{}{
+		{"id": 1, "title": "a2", "priority": 9},
+		{"id": 3, "title": "c", "priority": 1},
+	}, []string{"id"}, nil)
+	if err != nil {
+		t.Fatalf("UpsertBulk() with condition failed: %v", err)
+	}
+	got := tasks()
+	if got[0]["priority"] != int64(9) || got[2]["priority"] != int64(3) {
+		t.Errorf("Expected priorities 9 and 3, got %v", got)
+	}
+
+	// Conflicting rows are kept, new ones inserted
+	n, err = root.Clone().Table("tasks").OnConflictDoNothing().UpsertBulk([]map[string]interface{}{
+		{"id": 1, "title": "ignored"},
+		{"id": 4, "title": "d"},
+	}, []string{"id"}, nil)
+	if err != nil || n != 1 {
+		t.Errorf("Expected 1 inserted row, got %d (%v)", n, err)
+	}
+	if got := tasks(); len(got) != 4 || got[0]["title"] != "a2" {
+		t.Errorf("Unexpected rows %v", got)
+	}
+}
+
+func TestCreateOrUpdateUpdateColumns(t *testing.T) {
+	root := New(openTasksDB(t))
+	root.Clone().Table("tasks").Insert(map[string]interface{}{"title": "a", "status": "done"})
+
+	_, err := root.Clone().Table("tasks").OnConflictUpdate("title").
+		CreateOrUpdate(map[string]interface{}{"id": 1, "title": "b", "status": "open"}, []string{"id"})
+	if err != nil {
+		t.Fatalf("CreateOrUpdate() failed: %v", err)
+	}
+
+	rows, _ := root.Clone().Table("tasks").Select("title", "status").ToArray()
+	if len(rows) != 1 || rows[0]["title"] != "b" || rows[0]["status"] != "done" {
+		t.Errorf("Expected only the title updated, got %v", rows)
+	}
+}
+
+func TestUpsertRawValues(t *testing.T) {
+	root := New(openTasksDB(t))
+	root.Clone().Table("tasks").Insert(map[string]interface{}{"title": "x"})
+
+	n, err := root.Clone().Table("tasks").UpsertBulk([]map[string]interface{}{
+		{"id": 1, "title": Raw("? || ?", "a", "b")},
+		{"id": 2, "title": "c"},
+	}, []string{"id"}, nil)
+	if err != nil || n != 2 {
+		t.Fatalf("UpsertBulk() = %d, %v", n, err)
+	}
+
+	_, err = root.Clone().Table("tasks").CreateOrUpdate(map[string]interface{}{
+		"id": 2, "title": Raw("? || ?", "c", "d"),
+	}, []string{"id"})
+	if err != nil {
+		t.Fatalf("CreateOrUpdate() failed: %v", err)
+	}
+
+	rows, _ := root.Clone().Table("tasks").Select("title").OrderBy("id", "ASC").ToArray()
+	if len(rows) != 2 || rows[0]["title"] != "ab" || rows[1]["title"] != "cd" {
+		t.Errorf("Expected raw values bound in place, got %v", rows)
+	}
+}
+
+func TestUpsertSQL(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"id": 1, "title": "a", "priority": 2},
+		{"id": 2, "title": "b", "priority": 3},
+	}
+	tests := []struct {
+		dialect Dialect
+		run     func(*Builder)
+		want    string
+		args    int
+	}{
+		{
+			Postgres,
+			func(b *Builder) { b.UpsertBulk(rows, []string{"id"}, []string{"title"}) },
+			`INSERT INTO tasks (id, priority, title) VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT (id) DO UPDATE SET title = excluded.title`,
+			6,
+		},
+		{
+			SQLite,
+			func(b *Builder) {
+				b.OnConflictWhere("tasks.status <> ?", "locked").UpsertBulk(rows, []string{"id"}, nil)
+			},
+			`INSERT INTO tasks (id, priority, title) VALUES (?, ?, ?), (?, ?, ?) ON CONFLICT (id) ` +
+				`DO UPDATE SET priority = excluded.priority, title = excluded.title WHERE tasks.status <> ?`,
+			7,
+		},
+		{
+			MySQL,
+			func(b *Builder) { b.UpsertBulk(rows, []string{"id"}, []string{"priority"}) },
+			"INSERT INTO tasks (id, priority, title) VALUES (?, ?, ?), (?, ?, ?) ON DUPLICATE KEY UPDATE priority = VALUES(priority)",
+			6,
+		},
+		{
+			MySQL,
+			func(b *Builder) { b.OnConflictDoNothing().UpsertBulk(rows, []string{"id"}, nil) },
+			"INSERT INTO tasks (id, priority, title) VALUES (?, ?, ?), (?, ?, ?) ON DUPLICATE KEY UPDATE id = id",
+			6,
+		},
+		{
+			SQLServer,
+			func(b *Builder) {
+				b.OnConflictWhere("source.priority > target.priority").UpsertBulk(rows, []string{"id"}, []string{"priority"})
+			},
+			`MERGE INTO tasks AS target USING (VALUES (@p1, @p2, @p3), (@p4, @p5, @p6)) AS source (id, priority, title) ON target.id = source.id ` +
+				`WHEN MATCHED AND (source.priority > target.priority) THEN UPDATE SET target.priority = source.priority ` +
+				`WHEN NOT MATCHED THEN INSERT (id, priority, title) VALUES (source.id, source.priority, source.title);`,
+			6,
+		},
+		{
+			SQLServer,
+			func(b *Builder) {
+				b.OnConflictDoNothing().CreateOrUpdate(rows[0], []string{"id"})
+			},
+			`MERGE INTO tasks AS target USING (VALUES (@p1, @p2, @p3)) AS source (id, priority, title) ON target.id = source.id ` +
+				`WHEN NOT MATCHED THEN INSERT (id, priority, title) VALUES (source.id, source.priority, source.title);`,
+			3,
+		},
+	}
+
+	for _, tt := range tests {
+		var calls []string
+		hook := &recordHook{name: "rec", calls: &calls}
+		tt.run(New(openTasksDB(t), WithDialect(tt.dialect), WithHooks(hook, denyHook{})).Table("tasks"))
+
+		if len(hook.events) != 1 || hook.events[0].SQL != tt.want || len(hook.events[0].Args) != tt.args {
+			t.Errorf("[%s] expected %s with %d args, got %v", tt.dialect.Name(), tt.want, tt.args, hook.events)
+		}
+	}
+}
+
+func TestUpsertErrors(t *testing.T) {
+	root := New(openTasksDB(t))
+	row := []map[string]interface{}{{"id": 1, "title": "a"}}
+
+	if _, err := root.Clone().Table("tasks").UpsertBulk(row, []string{"id"}, []string{"status"}); err == nil {
+		t.Error("Expected error for an update column that is not written")
+	}
+	if _, err := root.Clone().Table("tasks").OnConflictDoNothing().OnConflictWhere("1 = 1").UpsertBulk(row, []string{"id"}, nil); err == nil {
+		t.Error("Expected error for a condition without columns to update")
+	}
+	b := root.Clone().Table("tasks")
+	if _, err := b.UpsertBulk([]map[string]interface{}{{"id": 1}, {"title": "b"}}, []string{"id"}, nil); err == nil {
+		t.Error("Expected error for rows with different columns")
+	}
+	if _, err := b.UpsertBulk(row, []string{"id"}, nil); err != nil {
+		t.Errorf("UpsertBulk() after a rejected call failed: %v", err)
+	}
+
+	mysql := New(openTasksDB(t), WithDialect(MySQL))
+	if _, err := mysql.Clone().Table("tasks").OnConflictWhere("1 = 1").CreateOrUpdate(row[0], []string{"id"}); err == nil {
+		t.Error("Expected error for a condition on mysql")
+	}
+}
@@ -0,0 +1,315 @@
+package gsorm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WindowSpec describes the OVER (...) clause of a window function:
+// gsorm.Window().PartitionBy("user_id").OrderBy("created_at", "ASC")
+type WindowSpec struct {
+	partitionBy []string
+	orderBy     []OrderCondition
+	frame       *windowFrame
+	err         error
+}
+
+// windowFrame is a ROWS, RANGE or GROUPS frame clause
+type windowFrame struct {
+	unit       string
+	start, end FrameBound
+}
+
+// FrameBound is one end of a window frame
+type FrameBound struct {
+	kind   string // UNBOUNDED PRECEDING, PRECEDING, CURRENT ROW, FOLLOWING, UNBOUNDED FOLLOWING
+	offset int
+}
+
+// Frame bounds without an offset
+var (
+	UnboundedPreceding = FrameBound{kind: "UNBOUNDED PRECEDING"}
+	CurrentRow         = FrameBound{kind: "CURRENT ROW"}
+	UnboundedFollowing = FrameBound{kind: "UNBOUNDED FOLLOWING"}
+)
+
+// Preceding is the bound n rows (or values, or groups) before the current row
+func Preceding(n int) FrameBound {
+	return FrameBound{kind: "PRECEDING", offset: n}
+}
+
+// Following is the bound n rows (or values, or groups) after the current row
+func Following(n int) FrameBound {
+	return FrameBound{kind: "FOLLOWING", offset: n}
+}
+
+// Window starts an empty window specification, i.e. OVER ()
+func Window() *WindowSpec {
+	return &WindowSpec{}
+}
+
+// PartitionBy adds PARTITION BY columns
+func (w *WindowSpec) PartitionBy(columns ...string) *WindowSpec {
+	w.partitionBy = append(w.partitionBy, columns...)
+	return w
+}
+
+// OrderBy adds an ORDER BY column to the window
+func (w *WindowSpec) OrderBy(column, direction string) *WindowSpec {
+	dir, err := orderDirection(direction)
+	if err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+		return w
+	}
+
+	w.orderBy = append(w.orderBy, OrderCondition{Column: column, Dir: dir})
+	return w
+}
+
+// Rows sets a ROWS BETWEEN start AND end frame
+func (w *WindowSpec) Rows(start, end FrameBound) *WindowSpec {
+	w.frame = &windowFrame{unit: "ROWS", start: start, end: end}
+	return w
+}
+
+// Range sets a RANGE BETWEEN start AND end frame
+func (w *WindowSpec) Range(start, end FrameBound) *WindowSpec {
+	w.frame = &windowFrame{unit: "RANGE", start: start, end: end}
+	return w
+}
+
+// Groups sets a GROUPS BETWEEN start AND end frame
+func (w *WindowSpec) Groups(start, end FrameBound) *WindowSpec {
+	w.frame = &windowFrame{unit: "GROUPS", start: start, end: end}
+	return w
+}
+
+// WindowFunc is a window function call such as ROW_NUMBER() or SUM(amount).
+// Add it to a query with Builder.SelectWindow.
+type WindowFunc struct {
+	name    string
+	column  string        // column argument, if any
+	extra   []int         // inline integer arguments (offsets, buckets)
+	params  []interface{} // bound arguments after the inline ones
+	ranking bool          // ranking or offset function: no frame, may need ORDER BY
+	window  *WindowSpec
+}
+
+// RowNumber numbers the rows of each partition starting at 1
+func RowNumber() *WindowFunc { return &WindowFunc{name: "ROW_NUMBER", ranking: true} }
+
+// Rank ranks rows with gaps after ties
+func Rank() *WindowFunc { return &WindowFunc{name: "RANK", ranking: true} }
+
+// DenseRank ranks rows without gaps after ties
+func DenseRank() *WindowFunc { return &WindowFunc{name: "DENSE_RANK", ranking: true} }
+
+// PercentRank returns the relative rank of each row between 0 and 1
+func PercentRank() *WindowFunc { return &WindowFunc{name: "PERCENT_RANK", ranking: true} }
+
+// CumeDist returns the cumulative distribution of each row
+func CumeDist() *WindowFunc { return &WindowFunc{name: "CUME_DIST", ranking: true} }
+
+// Ntile splits each partition into n buckets
+func Ntile(n int) *WindowFunc {
+	return &WindowFunc{name: "NTILE", extra: []int{n}, ranking: true}
+}
+
+// Lag returns column from offset rows before the current row. The optional
+// default is bound as a parameter and used when there is no such row.
+func Lag(column string, offset int, def ...interface{}) *WindowFunc {
+	return offsetFunc("LAG", column, offset, def)
+}
+
+// Lead returns column from offset rows after the current row. The optional
+// default is bound as a parameter and used when there is no such row.
+func Lead(column string, offset int, def ...interface{}) *WindowFunc {
+	return offsetFunc("LEAD", column, offset, def)
+}
+
+func offsetFunc(name, column string, offset int, def []interface{}) *WindowFunc {
+	fn := &WindowFunc{name: name, column: column, extra: []int{offset}, ranking: true}
+	if len(def) > 0 {
+		fn.params = def[:1]
+	}
+	return fn
+}
+
+// FirstValue returns column from the first row of the frame
+func FirstValue(column string) *WindowFunc { return &WindowFunc{name: "FIRST_VALUE", column: column} }
+
+// LastValue returns column from the last row of the frame
+func LastValue(column string) *WindowFunc { return &WindowFunc{name: "LAST_VALUE", column: column} }
+
+// Sum totals column over the frame, e.g. for running totals
+func Sum(column string) *WindowFunc { return &WindowFunc{name: "SUM", column: column} }
+
+// Avg averages column over the frame
+func Avg(column string) *WindowFunc { return &WindowFunc{name: "AVG", column: column} }
+
+// Count counts the rows of the frame; use "*" to count every row
+func Count(column string) *WindowFunc { return &WindowFunc{name: "COUNT", column: column} }
+
+// Min returns the smallest column value of the frame
+func Min(column string) *WindowFunc { return &WindowFunc{name: "MIN", column: column} }
+
+// Max returns the largest column value of the frame
+func Max(column string) *WindowFunc { return &WindowFunc{name: "MAX", column: column} }
+
+// Over sets the window the function is computed over
+func (f *WindowFunc) Over(w *WindowSpec) *WindowFunc {
+	f.window = w
+	return f
+}
+
+// SelectWindow adds a window function to the select list as alias.
+// The expression is rendered and checked against the dialect when
+// SelectWindow is called; an invalid window fails the query on execution.
+func (b *Builder) SelectWindow(fn *WindowFunc, alias string) *Builder {
+	expr, args, err := fn.build(b)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+
+	b.selectCols = append(b.selectCols, expr+" AS "+b.ident(alias))
+	b.selectArgs = append(b.selectArgs, args...)
+	return b
+}
+
+// build renders the function call and its OVER clause for b's dialect
+func (f *WindowFunc) build(b *Builder) (string, []interface{}, error) {
+	w := f.window
+	if w == nil {
+		w = Window()
+	}
+	if w.err != nil {
+		return "", nil, w.err
+	}
+	if err := f.validate(b.dialect, w); err != nil {
+		return "", nil, err
+	}
+
+	args := make([]string, 0, 2+len(f.params))
+	if f.column != "" {
+		args = append(args, b.ident(f.column))
+	}
+	for _, n := range f.extra {
+		args = append(args, strconv.Itoa(n))
+	}
+	for range f.params {
+		args = append(args, "?")
+	}
+
+	sb := getStringBuilder()
+	defer putStringBuilder(sb)
+
+	sb.WriteString(f.name + "(" + strings.Join(args, ", ") + ") OVER (")
+
+	clauses := make([]string, 0, 3)
+	if len(w.partitionBy) > 0 {
+		cols := make([]string, len(w.partitionBy))
+		for i, col := range w.partitionBy {
+			cols[i] = b.ident(col)
+		}
+		clauses = append(clauses, "PARTITION BY "+strings.Join(cols, ", "))
+	}
+
+	if len(w.orderBy) > 0 {
+		cols := make([]string, len(w.orderBy))
+		for i, order := range w.orderBy {
+			cols[i] = b.ident(order.Column) + " " + order.Dir
+		}
+		clauses = append(clauses, "ORDER BY "+strings.Join(cols, ", "))
+	}
+
+	if w.frame != nil {
+		clauses = append(clauses, fmt.Sprintf("%s BETWEEN %s AND %s",
+			w.frame.unit, w.frame.start.sql(), w.frame.end.sql()))
+	}
+
+	sb.WriteString(strings.Join(clauses, " "))
+	sb.WriteString(")")
+
+	return sb.String(), f.params, nil
+}
+
+// validate checks the window against generic SQL rules and the dialect
+func (f *WindowFunc) validate(d Dialect, w *WindowSpec) error {
+	support := d.WindowSupport()
+
+	if f.ranking && len(w.orderBy) == 0 && support.OrderedRanking {
+		return fmt.Errorf("gsorm: %s requires an ORDER BY window on %s", f.name, d.Name())
+	}
+	for _, n := range f.extra {
+		if n < 0 || (n == 0 && f.name == "NTILE") {
+			return fmt.Errorf("gsorm: invalid %s argument %d", f.name, n)
+		}
+	}
+
+	frame := w.frame
+	if frame == nil {
+		return nil
+	}
+	if f.ranking {
+		return fmt.Errorf("gsorm: %s does not accept a window frame", f.name)
+	}
+	if frame.unit == "GROUPS" && !support.GroupsFrames {
+		return fmt.Errorf("gsorm: GROUPS frames are not supported on %s", d.Name())
+	}
+	if frame.start.offset < 0 || frame.end.offset < 0 {
+		return fmt.Errorf("gsorm: window frame offsets must not be negative")
+	}
+	if frame.start == UnboundedFollowing || frame.end == UnboundedPreceding {
+		return fmt.Errorf("gsorm: invalid window frame %s BETWEEN %s AND %s",
+			frame.unit, frame.start.sql(), frame.end.sql())
+	}
+	if frame.start.rank() > frame.end.rank() {
+		return fmt.Errorf("gsorm: window frame starts after it ends")
+	}
+
+	if frame.unit == "RANGE" && (frame.start.hasOffset() || frame.end.hasOffset()) {
+		if !support.RangeOffsets {
+			return fmt.Errorf("gsorm: RANGE frames with offsets are not supported on %s", d.Name())
+		}
+		if len(w.orderBy) != 1 {
+			return fmt.Errorf("gsorm: RANGE frames with offsets require exactly one ORDER BY column")
+		}
+	}
+	if frame.unit == "GROUPS" && len(w.orderBy) == 0 {
+		return fmt.Errorf("gsorm: GROUPS frames require an ORDER BY window")
+	}
+
+	return nil
+}
+
+// sql renders the bound; offsets are plain integers and written inline
+func (fb FrameBound) sql() string {
+	if fb.hasOffset() {
+		return strconv.Itoa(fb.offset) + " " + fb.kind
+	}
+	return fb.kind
+}
+
+func (fb FrameBound) hasOffset() bool {
+	return fb.kind == "PRECEDING" || fb.kind == "FOLLOWING"
+}
+
+// rank orders bounds from the start of the partition to its end
+func (fb FrameBound) rank() int {
+	switch fb.kind {
+	case "UNBOUNDED PRECEDING":
+		return 0
+	case "PRECEDING":
+		return 1
+	case "CURRENT ROW":
+		return 2
+	case "FOLLOWING":
+		return 3
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,147 @@
+package gsorm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectWindowRowNumber(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	w := Window().PartitionBy("user_id").OrderBy("amount", "desc")
+	builder := DB().Table("orders").Select("id", "user_id").SelectWindow(RowNumber().Over(w), "rn")
+
+	query, _ := builder.buildSelectQuery()
+
+	expectedQuery := "SELECT id, user_id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY amount DESC) AS rn FROM orders"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+}
+
+func TestSelectWindowRunningTotal(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	setupOrdersTable(t, db)
+
+	w := Window().OrderBy("id", "ASC").Rows(UnboundedPreceding, CurrentRow)
+	results, err := DB().Table("orders").Select("id").
+		SelectWindow(Sum("amount").Over(w), "running_total").
+		OrderBy("id", "ASC").
+		ToArray()
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	expected := []float64{100, 150, 450, 470}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d rows, got %d", len(expected), len(results))
+	}
+	for i, row := range results {
+		if row["running_total"] != expected[i] {
+			t.Errorf("Row %d: expected running total %v, got %v", i, expected[i], row["running_total"])
+		}
+	}
+}
+
+func TestSelectWindowLagDefaultIsBound(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	setupOrdersTable(t, db)
+
+	w := Window().PartitionBy("user_id").OrderBy("id", "ASC")
+	builder := DB().Table("orders").Select("id").
+		SelectWindow(Lag("amount", 1, 0).Over(w), "previous").
+		Where("user_id", "=", 1).
+		OrderBy("id", "ASC")
+
+	query, args := builder.buildSelectQuery()
+
+	expectedQuery := "SELECT id, LAG(amount, 1, ?) OVER (PARTITION BY user_id ORDER BY id ASC) AS previous FROM orders WHERE user_id = ? ORDER BY id ASC"
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{0, 1}) {
+		t.Errorf("Expected args [0 1], got %v", args)
+	}
+
+	results, err := builder.ToArray()
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(results))
+	}
+	if results[0]["previous"] != int64(0) || results[1]["previous"] != float64(100) {
+		t.Errorf("Unexpected LAG values: %v, %v", results[0]["previous"], results[1]["previous"])
+	}
+}
+
+func TestSelectWindowRankAndFrames(t *testing.T) {
+	builder := newBuilder(nil, WithDialect(Postgres), WithIdentifierQuoting())
+
+	builder.Table("orders").Select("id").
+		SelectWindow(DenseRank().Over(Window().OrderBy("amount", "DESC")), "place").
+		SelectWindow(Avg("amount").Over(Window().PartitionBy("user_id").OrderBy("id", "ASC").Rows(Preceding(2), CurrentRow)), "moving_avg").
+		SelectWindow(Count("*").Over(nil), "total").
+		SelectWindow(Ntile(4).Over(Window().OrderBy("amount", "ASC")), "quartile")
+
+	query, _ := builder.buildSelectQuery()
+
+	expectedQuery := `SELECT "id", ` +
+		`DENSE_RANK() OVER (ORDER BY "amount" DESC) AS "place", ` +
+		`AVG("amount") OVER (PARTITION BY "user_id" ORDER BY "id" ASC ROWS BETWEEN 2 PRECEDING AND CURRENT ROW) AS "moving_avg", ` +
+		`COUNT(*) OVER () AS "total", ` +
+		`NTILE(4) OVER (ORDER BY "amount" ASC) AS "quartile" FROM "orders"`
+	if query != expectedQuery {
+		t.Errorf("Expected query:\n%s\nGot:\n%s", expectedQuery, query)
+	}
+}
+
+func TestSelectWindowDialectValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect Dialect
+		fn      *WindowFunc
+		err     string
+	}{
+		{"ranking without order on sqlserver", SQLServer, RowNumber().Over(Window().PartitionBy("user_id")), "requires an ORDER BY"},
+		{"groups on mysql", MySQL, Sum("amount").Over(Window().OrderBy("id", "ASC").Groups(Preceding(1), CurrentRow)), "GROUPS frames are not supported"},
+		{"range offsets on sqlserver", SQLServer, Sum("amount").Over(Window().OrderBy("id", "ASC").Range(Preceding(5), CurrentRow)), "RANGE frames with offsets"},
+		{"frame on ranking function", Postgres, Rank().Over(Window().OrderBy("id", "ASC").Rows(UnboundedPreceding, CurrentRow)), "does not accept a window frame"},
+		{"inverted frame", Postgres, Sum("amount").Over(Window().Rows(CurrentRow, Preceding(1))), "starts after it ends"},
+		{"negative offset", SQLite, Lead("amount", -1), "invalid LEAD argument"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := newBuilder(nil, WithDialect(tt.dialect)).Table("orders").SelectWindow(tt.fn, "w")
+
+			if builder.err == nil || !strings.Contains(builder.err.Error(), tt.err) {
+				t.Fatalf("Expected error containing %q, got %v", tt.err, builder.err)
+			}
+			if _, err := builder.Get(); err != builder.err {
+				t.Errorf("Expected Get to return the build error, got %v", err)
+			}
+		})
+	}
+
+	// Ranking without ORDER BY is fine where the dialect allows it
+	builder := newBuilder(nil, WithDialect(Postgres)).Table("orders").SelectWindow(RowNumber(), "rn")
+	if builder.err != nil {
+		t.Errorf("Unexpected error: %v", builder.err)
+	}
+}
+
+func TestSelectWindowErrorSurvivesClone(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	builder := DB().Table("users").SelectWindow(Lag("age", -2), "prev")
+
+	if _, err := builder.Clone().Count(); err == nil {
+		t.Error("Expected Count on a clone to return the window error")
+	}
+}